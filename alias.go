@@ -0,0 +1,177 @@
+package skel
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Prefix of -in values naming an alias of the user configuration.
+const ALIAS_PREFIX = "alias:"
+
+// A name for a skeleton, defined in the user configuration. With a version,
+// the source is a git repository and the skeleton is pinned to that tag or
+// commit.
+type SkeletonAlias struct {
+	Name    string `xml:"name,attr"`
+	Source  string `xml:"source,attr"`  // directory, zip file or git repository
+	Version string `xml:"version,attr"` // tag or commit of the git repository
+}
+
+// Returns the source of the alias, with its version if pinned.
+func (a SkeletonAlias) String() string {
+	if a.Version == "" {
+		return a.Source
+	}
+	return a.Source + "@" + a.Version
+}
+
+// Reports whether the source of the alias is a git repository, rather than a
+// local skeleton directory or zip file.
+func (a SkeletonAlias) isGit() bool {
+	if a.Version != "" {
+		return true
+	}
+	if strings.HasPrefix(a.Source, GALLERY_PREFIX) {
+		return false
+	}
+	_, err := os.Stat(expandHome(a.Source))
+	return err != nil
+}
+
+// Returns the alias with the given name.
+func (c UserConfig) Alias(name string) (SkeletonAlias, bool) {
+	for _, a := range c.Aliases {
+		if a.Name == name {
+			return a, true
+		}
+	}
+	return SkeletonAlias{}, false
+}
+
+// Matches scp-like git locations, like git@github.com:org/repo.
+var scpLike = regexp.MustCompile(`^[^/@:]+@[^/:]+:`)
+
+// Returns the URL git clones the source from. Sources without a scheme, like
+// github.com/org/repo, are cloned over https.
+func GitURL(source string) string {
+	source = expandHome(source)
+	if strings.Contains(source, "://") || scpLike.MatchString(source) {
+		return source
+	}
+	if _, err := os.Stat(source); err == nil {
+		return source
+	}
+	return "https://" + source
+}
+
+// Runs git with the given arguments, reporting its error output on failure.
+func runGit(args ...string) ([]byte, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command("git", args...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("git: %s", msg)
+		}
+		return nil, fmt.Errorf("git: %s", err)
+	}
+	return out, nil
+}
+
+// Clones the git repository at url into dir, and checks out the version when
+// given. The .git directory is removed, so only the skeleton remains.
+func cloneGit(url, version, dir string) error {
+	if _, err := runGit("clone", "--quiet", "--no-checkout", "--", url, dir); err != nil {
+		return err
+	}
+	if version == "" {
+		version = "HEAD"
+	}
+	if _, err := runGit("-C", dir, "checkout", "--quiet", version, "--"); err != nil {
+		return err
+	}
+	return os.RemoveAll(filepath.Join(dir, ".git"))
+}
+
+// Matches the characters which are replaced in the names of pin directories.
+var pinEscape = regexp.MustCompile(`[^\w.-]+`)
+
+// Returns the directory in the cache holding the pinned version of the alias.
+func pinDir(a SkeletonAlias) string {
+	name := pinEscape.ReplaceAllString(a.Source, "_") + "@" + pinEscape.ReplaceAllString(a.Version, "_")
+	return filepath.Join(cacheDir(), "pins", name)
+}
+
+// Returns the location of the skeleton of the alias. Pinned versions are
+// cloned once and kept in the cache, since tags and commits are not supposed
+// to change; unpinned git repositories are cloned every time.
+func ResolveAlias(a SkeletonAlias) (string, error) {
+	if !a.isGit() {
+		return expandHome(a.Source), nil
+	}
+
+	if a.Version == "" {
+		dir, err := temps.Dir("skel-alias")
+		if err != nil {
+			return "", err
+		}
+		return dir, cloneGit(GitURL(a.Source), "", dir)
+	}
+
+	dir := pinDir(a)
+	if _, err := os.Stat(dir); err == nil {
+		return dir, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+		return "", err
+	}
+	// clone next to the final location, so an interrupted clone is never
+	// mistaken for a complete one
+	tmp, err := ioutil.TempDir(filepath.Dir(dir), ".clone-")
+	if err != nil {
+		return "", err
+	}
+	debugf("Cloning '%s' at %s\n", a.Source, a.Version)
+	if err := cloneGit(GitURL(a.Source), a.Version, tmp); err != nil {
+		os.RemoveAll(tmp)
+		return "", err
+	}
+	if err := os.Rename(tmp, dir); err != nil {
+		os.RemoveAll(tmp)
+		return "", err
+	}
+	return dir, nil
+}
+
+// Matches tags which are versions, like v2.3 or 1.0.1.
+var versionTag = regexp.MustCompile(`^v?\d+(\.\d+)*$`)
+
+// Returns the latest of the version-like tags of the git repository at url,
+// or an empty string when it has none.
+func LatestTag(url string) (string, error) {
+	out, err := runGit("ls-remote", "--tags", "--refs", "--", url)
+	if err != nil {
+		return "", err
+	}
+	latest := ""
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		tag := strings.TrimPrefix(fields[1], "refs/tags/")
+		if versionTag.MatchString(tag) && (latest == "" || CompareVersions(tag, latest) > 0) {
+			latest = tag
+		}
+	}
+	return latest, nil
+}
@@ -0,0 +1,58 @@
+package skel
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+const (
+	// Name of the file with the answers, written into every generated project.
+	ANSWERS_FILE = ".skel-answers.json"
+)
+
+// The answers a project was generated with, and the skeleton they answer.
+// Unlike the manifest, which records the generated files as well, they are
+// meant to be kept, so the project can be generated again with skel regen.
+type Answers struct {
+	Skeleton ManifestSkeleton  `json:"skeleton"`
+	Answers  map[string]string `json:"answers"`
+}
+
+// Writes the answers of the skeleton to the sink.
+func (t Skeleton) WriteAnswers(sink Sink) error {
+	a := Answers{
+		Skeleton: ManifestSkeleton{t.Config.Name, t.Config.Version, t.manifestSource()},
+		Answers:  t.KeyValues,
+	}
+	data, err := json.MarshalIndent(a, "", "  ")
+	if err != nil {
+		return err
+	}
+	return sink.WriteFile(ANSWERS_FILE, bytes.NewReader(append(data, '\n')), 0644)
+}
+
+// Reads the answers of the project in the given directory. Projects generated
+// before answers were written have them in their manifest only, which is read
+// instead.
+func ReadAnswers(dir string) (Answers, error) {
+	a := Answers{}
+	data, err := ioutil.ReadFile(filepath.Join(dir, ANSWERS_FILE))
+	if os.IsNotExist(err) {
+		m, merr := ReadManifest(dir)
+		if merr != nil {
+			return a, err
+		}
+		return Answers{m.Skeleton, m.Answers}, nil
+	}
+	if err != nil {
+		return a, err
+	}
+	if err := json.Unmarshal(data, &a); err != nil {
+		return a, fmt.Errorf("invalid %s: %s", ANSWERS_FILE, err)
+	}
+	return a, nil
+}
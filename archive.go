@@ -0,0 +1,167 @@
+package skel
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Reports whether the file starts with the magic number of gzip, for archives
+// whose name does not tell, like a downloaded GitHub tarball.
+func isGzipFile(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	magic := make([]byte, 2)
+	_, err = io.ReadFull(f, magic)
+	return err == nil && magic[0] == 0x1f && magic[1] == 0x8b
+}
+
+// Extracts the zip or gzipped tar file to a temporary directory, telling them
+// apart by name or, failing that, by contents.
+func unpackArchive(archive string) (string, error) {
+	kind := archiveKind(archive)
+	if kind == "" && isGzipFile(archive) {
+		kind = "tar.gz"
+	}
+	if kind == "tar.gz" {
+		dir, err := Untar(archive)
+		if err != nil {
+			return "", fmt.Errorf("tar.gz does not seem to be OK: %s", err)
+		}
+		return dir, nil
+	}
+	dir, err := Unzip(archive)
+	if err != nil {
+		return "", fmt.Errorf("ZIP does not seem to be OK: %s", err)
+	}
+	return dir, nil
+}
+
+// Untar extracts the given gzipped tar file to a temporary directory, keeping
+// the permissions and modification times of its entries. Returns the
+// directory.
+func Untar(tarball string) (gendir string, err error) {
+	f, err := os.Open(tarball)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return "", err
+	}
+	defer gz.Close()
+
+	// create temp dir, which is removed when skel exits
+	targetDir, err := temps.Dir("skel")
+	if err != nil {
+		return "", err
+	}
+
+	debugf("Using temporary directory '%s'\n", targetDir)
+
+	// symbolic and hard links are created last, so no file is extracted
+	// through one
+	var links []*tar.Header
+	r := tar.NewReader(gz)
+	for {
+		hdr, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return targetDir, err
+		}
+		if hdr.Typeflag == tar.TypeSymlink || hdr.Typeflag == tar.TypeLink {
+			links = append(links, hdr)
+			continue
+		}
+		if err := untarFile(hdr, r, targetDir); err != nil {
+			return targetDir, err
+		}
+	}
+	for _, hdr := range links {
+		if err := untarLink(hdr, targetDir); err != nil {
+			return targetDir, err
+		}
+	}
+
+	return targetDir, nil
+}
+
+// Extracts a single file or directory from a tar into targetDir.
+func untarFile(hdr *tar.Header, r io.Reader, targetDir string) error {
+	creationTarget := filepath.Join(targetDir, hdr.Name)
+	if !withinDir(targetDir, creationTarget) {
+		return fmt.Errorf("'%s' is outside of the tar file's directory", hdr.Name)
+	}
+	if err := checkParents(targetDir, creationTarget); err != nil {
+		return err
+	}
+	mode := hdr.FileInfo().Mode()
+
+	switch hdr.Typeflag {
+	case tar.TypeDir:
+		debugf("Creating directory '%s'\n", hdr.Name)
+		return os.MkdirAll(creationTarget, Entry{Dir: true, Mode: mode}.perm())
+	case tar.TypeReg, tar.TypeRegA:
+	case tar.TypeXGlobalHeader:
+		// like the pax_global_header of GitHub tarballs
+		return nil
+	default:
+		return fmt.Errorf("'%s' is not a file, directory or link", hdr.Name)
+	}
+
+	// it's a file, create it, keeping its permissions and modification time
+	if err := os.MkdirAll(filepath.Dir(creationTarget), 0755); err != nil {
+		return err
+	}
+	newfile, err := os.OpenFile(creationTarget, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, Entry{Mode: mode}.perm())
+	if err != nil {
+		return err
+	}
+	defer newfile.Close()
+
+	debugf("Untarring file '%s'\n", hdr.Name)
+	if _, err = io.Copy(newfile, r); err != nil {
+		return err
+	}
+	if err := newfile.Close(); err != nil {
+		return err
+	}
+	return os.Chtimes(creationTarget, hdr.ModTime, hdr.ModTime)
+}
+
+// Creates a symbolic or hard link from a tar in targetDir. A hard link must
+// refer to a file within the tar.
+func untarLink(hdr *tar.Header, targetDir string) error {
+	creationTarget := filepath.Join(targetDir, hdr.Name)
+	if !withinDir(targetDir, creationTarget) {
+		return fmt.Errorf("'%s' is outside of the tar file's directory", hdr.Name)
+	}
+	if err := checkParents(targetDir, creationTarget); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(creationTarget), 0755); err != nil {
+		return err
+	}
+
+	debugf("Creating link '%s'\n", hdr.Name)
+	if hdr.Typeflag == tar.TypeSymlink {
+		return os.Symlink(hdr.Linkname, creationTarget)
+	}
+	linked := filepath.Join(targetDir, hdr.Linkname)
+	if !withinDir(targetDir, linked) {
+		return fmt.Errorf("'%s' links to '%s', outside of the tar file's directory", hdr.Name, hdr.Linkname)
+	}
+	if err := checkParents(targetDir, linked); err != nil {
+		return err
+	}
+	return os.Link(linked, creationTarget)
+}
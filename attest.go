@@ -0,0 +1,220 @@
+package skel
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// Name of the provenance attestation written into a generated project
+	// with -attest, and of its detached ed25519 signature.
+	ATTESTATION_FILE      = ".skel-provenance.json"
+	ATTESTATION_SIGNATURE = ".skel-provenance.json.sig"
+
+	// Type of the attestation document.
+	ATTESTATION_TYPE = "https://github.com/krpors/skel/provenance/v1"
+)
+
+// Provenance of a generated project: how, from what and when it was
+// generated.
+type Attestation struct {
+	Type             string              `json:"type"`
+	SkelVersion      string              `json:"skelVersion"`
+	Skeleton         AttestationSkeleton `json:"skeleton"`
+	ParametersDigest string              `json:"parametersDigest"` // digest of the answers, as JSON
+	OutputDigest     string              `json:"outputDigest"`     // digest of the manifest, which lists the digests of all files
+	Timestamp        string              `json:"timestamp"`
+}
+
+// The skeleton a project was generated from.
+type AttestationSkeleton struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Source  string `json:"source"`
+	Digest  string `json:"digest"` // digest of all files of the skeleton
+}
+
+// Returns the digest of the skeleton in dir, over the paths and contents of
+// all its files.
+func SkeletonDigest(dir string) (string, error) {
+	files := make(map[string]string)
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = digest(data)
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	var paths []string
+	for p := range files {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	var sb strings.Builder
+	for _, p := range paths {
+		fmt.Fprintf(&sb, "%s  %s\n", files[p], p)
+	}
+	return digest([]byte(sb.String())), nil
+}
+
+// Returns the time of the attestation, which is SOURCE_DATE_EPOCH when set.
+func attestationTime() time.Time {
+	if epoch, err := strconv.ParseInt(os.Getenv("SOURCE_DATE_EPOCH"), 10, 64); err == nil {
+		return time.Unix(epoch, 0).UTC()
+	}
+	return time.Now().UTC()
+}
+
+// Returns the attestation of the output, of which manifest is the manifest
+// as written.
+func (t Skeleton) Attestation(manifest []byte) (Attestation, error) {
+	skeleton, err := SkeletonDigest(t.Location)
+	if err != nil {
+		return Attestation{}, err
+	}
+	answers, err := json.Marshal(t.KeyValues)
+	if err != nil {
+		return Attestation{}, err
+	}
+	return Attestation{
+		Type:        ATTESTATION_TYPE,
+		SkelVersion: VERSION,
+		Skeleton: AttestationSkeleton{
+			Name:    t.Config.Name,
+			Version: t.Config.Version,
+			Source:  t.manifestSource(),
+			Digest:  skeleton,
+		},
+		ParametersDigest: digest(answers),
+		OutputDigest:     digest(manifest),
+		Timestamp:        attestationTime().Format(time.RFC3339),
+	}, nil
+}
+
+// Reads a PEM encoded key from the file, which is either a PKCS #8 private
+// key or a PKIX public key.
+func readPEMKey(path string) (interface{}, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("'%s' is not PEM encoded", path)
+	}
+	if block.Type == "PUBLIC KEY" {
+		return x509.ParsePKIXPublicKey(block.Bytes)
+	}
+	return x509.ParsePKCS8PrivateKey(block.Bytes)
+}
+
+// Reads the ed25519 private key with which attestations are signed.
+func ReadSigningKey(path string) (ed25519.PrivateKey, error) {
+	key, err := readPEMKey(path)
+	if err != nil {
+		return nil, err
+	}
+	private, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("'%s' is not an ed25519 private key", path)
+	}
+	return private, nil
+}
+
+// Writes the attestation of the output and its signature to the sink.
+func (t Skeleton) WriteAttestation(sink Sink, entries []Entry, key ed25519.PrivateKey) error {
+	manifest, err := t.manifestData(entries)
+	if err != nil {
+		return err
+	}
+	a, err := t.Attestation(manifest)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(a, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	signature, err := key.Sign(rand.Reader, data, crypto.Hash(0))
+	if err != nil {
+		return err
+	}
+
+	if err := sink.WriteFile(ATTESTATION_FILE, bytes.NewReader(data), 0644); err != nil {
+		return err
+	}
+	sig := base64.StdEncoding.EncodeToString(signature) + "\n"
+	return sink.WriteFile(ATTESTATION_SIGNATURE, strings.NewReader(sig), 0644)
+}
+
+// Verifies the attestation of the project in dir with the PEM encoded ed25519
+// public key in the file keyPath: its signature, and whether it attests the
+// manifest of the project. Returns the attestation.
+func VerifyAttestation(dir, keyPath string) (Attestation, error) {
+	a := Attestation{}
+	key, err := readPEMKey(keyPath)
+	if err != nil {
+		return a, err
+	}
+	public, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return a, fmt.Errorf("'%s' is not an ed25519 public key", keyPath)
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, ATTESTATION_FILE))
+	if err != nil {
+		return a, err
+	}
+	sig, err := ioutil.ReadFile(filepath.Join(dir, ATTESTATION_SIGNATURE))
+	if err != nil {
+		return a, err
+	}
+	signature, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(sig)))
+	if err != nil {
+		return a, fmt.Errorf("invalid %s: %s", ATTESTATION_SIGNATURE, err)
+	}
+	if !ed25519.Verify(public, data, signature) {
+		return a, fmt.Errorf("the signature of %s does not match", ATTESTATION_FILE)
+	}
+
+	if err := json.Unmarshal(data, &a); err != nil {
+		return a, fmt.Errorf("invalid %s: %s", ATTESTATION_FILE, err)
+	}
+	manifest, err := ioutil.ReadFile(filepath.Join(dir, MANIFEST_FILE))
+	if err != nil {
+		return a, err
+	}
+	if digest(manifest) != a.OutputDigest {
+		return a, fmt.Errorf("%s does not attest this %s", ATTESTATION_FILE, MANIFEST_FILE)
+	}
+	return a, nil
+}
@@ -0,0 +1,106 @@
+package skel
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// Number of bytes at the start of a file in which binary content is detected.
+const BINARY_SNIFF_LEN = 8000
+
+// Reports whether the file at path is binary. Like git, a file is regarded as
+// binary when its first 8000 bytes contain a NUL byte.
+func isBinaryFile(path string) (bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	head := make([]byte, BINARY_SNIFF_LEN)
+	n, err := io.ReadFull(f, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return false, err
+	}
+	return bytes.IndexByte(head[:n], 0) >= 0, nil
+}
+
+// Reports whether the skeleton file at path, which is rel relative to the
+// skeleton, is copied byte-for-byte: when its contents are not substituted,
+// when it matches a glob of <raw>, or when it is binary.
+func (t Skeleton) raw(rel, path string) (bool, error) {
+	if _, content := t.substitutes(rel, false); !content {
+		return true, nil
+	}
+	slashed := filepath.ToSlash(rel)
+	for _, glob := range listItems(t.Config.Raw) {
+		if matchGlob(glob, slashed) {
+			return true, nil
+		}
+	}
+	return isBinaryFile(path)
+}
+
+// Returns the digest of the file at path, reading it as a stream.
+func fileDigest(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("sha256:%x", h.Sum(nil)), nil
+}
+
+// Opens the contents of the file: the rendered contents, or the skeleton file
+// for raw files, which are not read into memory.
+func (e Entry) Open() (io.ReadCloser, error) {
+	if e.Raw {
+		return os.Open(e.Source)
+	}
+	return ioutil.NopCloser(bytes.NewReader(e.Content)), nil
+}
+
+// Returns the contents of the file, reading the skeleton file for raw files.
+func (e Entry) Bytes() ([]byte, error) {
+	if e.Raw {
+		return ioutil.ReadFile(e.Source)
+	}
+	return e.Content, nil
+}
+
+// Returns the size of the contents of the file, which for a symbolic link is
+// the length of its target.
+func (e Entry) Size() (int64, error) {
+	switch {
+	case e.Link != "":
+		return int64(len(e.Link)), nil
+	case e.Raw:
+		info, err := os.Stat(e.Source)
+		if err != nil {
+			return 0, err
+		}
+		return info.Size(), nil
+	}
+	return int64(len(e.Content)), nil
+}
+
+// Returns the digest of the contents of the file, as recorded in the manifest.
+func (e Entry) contentDigest() string {
+	if e.Link != "" {
+		return digest([]byte(e.Link))
+	}
+	if e.Raw {
+		return e.sum
+	}
+	return digest(e.Content)
+}
@@ -0,0 +1,45 @@
+package skel
+
+import (
+	"os"
+	"os/user"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Layout of ${__date}.
+const DATE_LAYOUT = "2006-01-02"
+
+// Returns the date of generation, for ${__date} and ${__year}. Like the times
+// of archive entries, it honors SOURCE_DATE_EPOCH, so output can be
+// reproduced.
+func generationDate() string {
+	now := time.Now()
+	if epoch, err := strconv.ParseInt(os.Getenv("SOURCE_DATE_EPOCH"), 10, 64); err == nil {
+		now = time.Unix(epoch, 0).UTC()
+	}
+	return now.Format(DATE_LAYOUT)
+}
+
+// Returns the name of the user running skel, for ${__user}, without the
+// domain Windows prefixes it with. Falls back to $USER or $USERNAME.
+func userName() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		name := u.Username
+		if i := strings.LastIndex(name, `\`); i >= 0 {
+			name = name[i+1:]
+		}
+		return name
+	}
+	if name := os.Getenv("USER"); name != "" {
+		return name
+	}
+	return os.Getenv("USERNAME")
+}
+
+// Reports whether the variable name is reserved for built-in variables: the
+// ${skel.*} and ${__*} variables, and those of loops.
+func isBuiltinName(name string) bool {
+	return strings.HasPrefix(name, "skel.") || strings.HasPrefix(name, "loop.") || strings.HasPrefix(name, "__")
+}
@@ -0,0 +1,114 @@
+package skel
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// The options a parameter can be answered with. Options are listed in the
+// skeleton, or sourced at prompt time from a file or the output of a command,
+// with one option per line.
+type ParamChoices struct {
+	Command string   `xml:"command,attr"` // run with the system shell, substituted
+	File    string   `xml:"file,attr"`    // relative to the skeleton, substituted
+	Options []string `xml:"choice"`
+}
+
+// Returns the non-empty, trimmed lines of the data, without duplicates.
+func optionLines(data []byte) []string {
+	var options []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" && !contains(options, line) {
+			options = append(options, line)
+		}
+	}
+	return options
+}
+
+// Returns the command run with the system shell: sh, or cmd on Windows.
+func shellCommand(command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.Command("cmd", "/C", command)
+	}
+	return exec.Command("sh", "-c", command)
+}
+
+// Quotes s as a single word of a command run by shellCommand.
+func shellWord(s string) string {
+	if runtime.GOOS == "windows" {
+		return cmdQuote(s)
+	}
+	return shellQuote(s)
+}
+
+// Runs the command with the system shell, and returns its standard output. The
+// error includes what the command wrote to its standard error.
+func runShell(command string) ([]byte, error) {
+	cmd := shellCommand(command)
+	stderr := new(bytes.Buffer)
+	cmd.Stderr = stderr
+	out, err := cmd.Output()
+	if msg := strings.TrimSpace(stderr.String()); err != nil && msg != "" {
+		return nil, fmt.Errorf("%s: %s", err, msg)
+	}
+	return out, err
+}
+
+// Returns the options of the choices, in the order given. Options from a file
+// or command are read every time, so they are always up to date. The file
+// name and command are substituted with the answers given so far.
+func (t Skeleton) ChoiceOptions(c ParamChoices) ([]string, error) {
+	var options []string
+	for _, o := range c.Options {
+		if o = strings.TrimSpace(o); o != "" && !contains(options, o) {
+			options = append(options, o)
+		}
+	}
+
+	if c.File != "" {
+		path, err := t.findReplace(c.File)
+		if err != nil {
+			return nil, err
+		}
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(t.Location, path)
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, o := range optionLines(data) {
+			if !contains(options, o) {
+				options = append(options, o)
+			}
+		}
+	}
+
+	if c.Command != "" {
+		// like hooks, so an earlier answer is never run as a command
+		quoted := t
+		quoted.quote = shellWord
+		command, err := quoted.findReplace(c.Command)
+		if err != nil {
+			return nil, err
+		}
+		out, err := runShell(command)
+		if err != nil {
+			return nil, fmt.Errorf("'%s' failed: %s", command, err)
+		}
+		for _, o := range optionLines(out) {
+			if !contains(options, o) {
+				options = append(options, o)
+			}
+		}
+	}
+
+	return options, nil
+}
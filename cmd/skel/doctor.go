@@ -0,0 +1,37 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/krpors/skel"
+)
+
+// Runs the doctor command: skel doctor [-in skeleton] [-out dir].
+func runDoctor(args []string) {
+	fs := flag.NewFlagSet("doctor", flag.ExitOnError)
+	in := fs.String("in", "", "skeleton to check, besides the environment")
+	out := fs.String("out", *flagOut, "output directory to check")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s doctor [-in skeleton] [-out dir]\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Checks the environment, and optionally a skeleton, for common problems.\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	findings := skel.CheckEnvironment(*out)
+	if *in != "" {
+		findings = append(findings, skel.CheckSkeleton(*in)...)
+	}
+
+	for _, f := range findings {
+		fmt.Printf("%-4s  %s\n", f.Severity, f.Message)
+		if f.Fix != "" {
+			fmt.Printf("      fix: %s\n", f.Fix)
+		}
+	}
+	if skel.Failed(findings) {
+		exit(1)
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/krpors/skel"
+)
+
+var (
+	exitLock  sync.Mutex
+	exitFuncs []func()
+)
+
+// Registers a function to be called when skel exits through exit or fatalf.
+// Functions are called in reverse order of registration, before locks are
+// released and the temporary directories are removed.
+func atExit(f func()) {
+	exitLock.Lock()
+	defer exitLock.Unlock()
+	exitFuncs = append(exitFuncs, f)
+}
+
+// Calls the registered exit functions, releases all locks, removes all
+// temporary directories and exits with the given code. Every exit of skel
+// should go through here, since os.Exit does not run deferred calls.
+func exit(code int) {
+	exitLock.Lock()
+	for i := len(exitFuncs) - 1; i >= 0; i-- {
+		exitFuncs[i]()
+	}
+	exitFuncs = nil
+	exitLock.Unlock()
+
+	if err := skel.Cleanup(); err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+	}
+	os.Exit(code)
+}
+
+// Prints the formatted error message to the standard error and exits with
+// code 1, after removing all temporary directories. With -json, the message
+// is reported as well.
+func fatalf(format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	reportFatal(msg)
+	fmt.Fprint(os.Stderr, msg)
+	exit(1)
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/krpors/skel"
+)
+
+// Runs the extract command, which creates a skeleton from an existing project:
+// skel extract -param name=value [-param ...] <project> <skeleton>.
+func runExtract(args []string) {
+	fs := flag.NewFlagSet("extract", flag.ExitOnError)
+	values := make(paramValues)
+	fs.Var(values, "param", "value of the project which becomes a parameter, as name=value (repeatable)")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s extract -param name=value [-param ...] <project> <skeleton>\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Creates a skeleton from an existing project, replacing every occurrence of\n")
+		fmt.Fprintf(os.Stderr, "the values in paths and contents with ${name}. The skeleton is written to a\n")
+		fmt.Fprintf(os.Stderr, "new directory, or to an archive when its name ends in .zip, .tar.gz or .tgz.\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 2 || len(values) == 0 {
+		fs.Usage()
+		exit(1)
+	}
+
+	result, err := skel.Extract(fs.Arg(0), fs.Arg(1), values)
+	if err != nil {
+		fatalf("Unable to extract a skeleton from '%s': %s\n", fs.Arg(0), err)
+	}
+	for _, w := range result.Warnings {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", w)
+	}
+	for _, name := range result.Unused {
+		fmt.Fprintf(os.Stderr, "Warning: the value of '%s' does not occur in the project, it is left out\n", name)
+	}
+
+	fmt.Printf("Extracted %d file(s) into '%s', with %d parameter(s):\n", result.Files, fs.Arg(1), len(result.Parameters))
+	for _, name := range result.Parameters {
+		fmt.Printf("  ${%s} (%s)\n", name, values[name])
+	}
+	if len(result.Raw) > 0 {
+		fmt.Printf("Files containing ${ are copied as-is (see <raw> in config.xml):\n")
+		for _, rel := range result.Raw {
+			fmt.Printf("  %s\n", rel)
+		}
+	}
+}
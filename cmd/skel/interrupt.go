@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/krpors/skel"
+)
+
+const (
+	// Exit code used when skel is stopped by SIGINT or SIGTERM.
+	EXIT_INTERRUPTED = 130
+)
+
+// Installs the handler for SIGINT and SIGTERM. On either signal, generation
+// is stopped after the file currently being written, and skel exits with
+// EXIT_INTERRUPTED, cleaning up like on any other exit.
+func handleInterrupts() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		sig := <-signals
+		fmt.Fprintf(os.Stderr, "\nReceived %s, stopping.\n", sig)
+		reportFatal(fmt.Sprintf("Received %s, stopping.", sig))
+
+		// walking stops at the next file or directory
+		skel.Abort()
+
+		exit(EXIT_INTERRUPTED)
+	}()
+}
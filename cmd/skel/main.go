@@ -0,0 +1,313 @@
+package main
+
+import (
+	"bufio"
+	"crypto/ed25519"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/krpors/skel"
+)
+
+var (
+	flagVerbose   *bool          = flag.Bool("verbose", false, "enable verbose output")
+	flagIn        *string        = flag.String("in", "", "input skeleton: directory, zip or tar.gz file, git repository or URL of an archive")
+	flagDryRun    *bool          = flag.Bool("dry", false, "initate a dry run (i.e. do not create files/dirs)")
+	flagOut       *string        = flag.String("out", "./__out/", "output directory with the generated structure")
+	flagSeed      *int64         = flag.Int64("seed", 0, "seed for random values, making them deterministic (0 = random seed)")
+	flagOwner     *string        = flag.String("owner", "", "owner of generated files, as user[:group] (names or ids)")
+	flagName      *string        = flag.String("name", "", "name of the output directory, which may contain ${x} (default from the skeleton, or name-timestamp)")
+	flagTarget    *string        = flag.String("target", "", "directory to generate the output in directly, instead of a new directory within -out")
+	flagNoSuffix  *bool          = flag.Bool("no-suffix", false, "name the output directory after the skeleton only, without the time")
+	flagConflict  *string        = flag.String("on-conflict", skel.CONFLICT_FAIL, "what to do with files which exist already in the output: fail, overwrite, skip, or prompt for each file")
+	flagParams    paramValues    = paramFlag("param", "value of a parameter as key=value, instead of asking for it (repeatable)")
+	flagParamFile *string        = flag.String("param-file", "", "JSON or YAML file with the values of parameters, instead of asking for them")
+	flagNoInput   *bool          = flag.Bool("no-input", false, "never ask anything, failing when a parameter has no value or default")
+	flagPlain     *bool          = flag.Bool("plain", false, "plain prompts, one line per question, for screen readers and restricted terminals (default when TERM is dumb)")
+	flagAttest    *string        = flag.String("attest", "", "PEM file with an ed25519 private key, with which a provenance attestation of the output is signed")
+	flagLockWait  *time.Duration = flag.Duration("lockwait", 30*time.Second, "how long to wait for another run generating into the same output directory")
+	flagNoHooks   *bool          = flag.Bool("no-hooks", false, "do not run the pre-generate and post-generate hooks of the skeleton")
+	flagKeepTimes *bool          = flag.Bool("keep-times", false, "give generated files the modification times of the skeleton files")
+	flagRollback  *bool          = flag.Bool("rollback", false, "remove what was created, and restore replaced files, when generating fails")
+	flagJSON      *bool          = flag.Bool("json", false, "print a report of the run as JSON (created paths, unsubstituted variables, errors), and everything else on the standard error")
+)
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "%s v%s\n\n", os.Args[0], skel.VERSION)
+	fmt.Fprintf(os.Stderr, "Generates directories, files and contents based on a 'skeleton' structure.\n")
+	fmt.Fprintf(os.Stderr, "All values in the form of ${x} are substituted, in directory/file names,\n")
+	fmt.Fprintf(os.Stderr, "but also in content of files. The values for these variables are requested\n")
+	fmt.Fprintf(os.Stderr, "on the standard input when a correct skeleton input is specified.\n\n")
+	fmt.Fprintf(os.Stderr, "The built-in variables ${skel.version}, ${skel.skeletonname},\n")
+	fmt.Fprintf(os.Stderr, "${skel.skeletonversion}, ${skel.outdir}, ${skel.uuid} and ${skel.randomhex},\n")
+	fmt.Fprintf(os.Stderr, "and ${__date}, ${__year}, ${__user} and ${__uuid} are always available.\n")
+	fmt.Fprintf(os.Stderr, "Random values are reproducible using -seed.\n")
+	fmt.Fprintf(os.Stderr, "Every parameter ${x} is also available as ${x.slug}, ${x.camel},\n")
+	fmt.Fprintf(os.Stderr, "${x.pascal}, ${x.snake} and ${x.envprefix}.\n\n")
+	fmt.Fprintf(os.Stderr, "When -out is not given and the standard input is a terminal, the output\n")
+	fmt.Fprintf(os.Stderr, "directory is asked for, offering the directories in $%s.\n\n", skel.ENV_WORKSPACES)
+
+	fmt.Fprintf(os.Stderr, "Usage:\n\n")
+	fmt.Fprintf(os.Stderr, "  %s [flags]            generate output from a skeleton\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "  %s new <skeleton>     generate output from an alias or bundled skeleton\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "  %s gen <name>         generate output from an installed skeleton\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "  %s list               list the installed skeletons\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "  %s install <skeleton> install a skeleton, in $%s or ~/.skel\n", os.Args[0], skel.ENV_HOME)
+	fmt.Fprintf(os.Stderr, "  %s remove <name>      remove an installed skeleton\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "  %s pin [alias]        list, add or update skeleton aliases\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "  %s regen <project>    generate a project again with its recorded answers\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "  %s verify <project>   report drift of a generated project\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "  %s undo <project>     remove what the last generation created\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "  %s doctor [-in dir]   check the environment and a skeleton for problems\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "  %s extract <project>  create a skeleton from an existing project\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "  %s pack <skeleton>    bundle a skeleton into a zip or tar.gz file\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "  %s schema <skeleton>  print the JSON Schema of the parameters\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "  %s upgrade            upgrade to the latest release\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "  %s version [-json]    print version and build information\n\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "Flags:\n\n")
+	flag.PrintDefaults()
+}
+
+// Buffered reader on the standard input, shared by all prompts.
+var stdin = bufio.NewReader(os.Stdin)
+
+// Whether the end of the standard input was reached, so prompts which repeat
+// until a valid answer is given can stop.
+var stdinEOF bool
+
+// Reads a single line from the standard input, without the line terminator.
+func readLine() string {
+	line, err := stdin.ReadString('\n')
+	if err == io.EOF {
+		stdinEOF = true
+	}
+	return strings.TrimRight(line, "\r\n")
+}
+
+// Reports whether the flag with the given name was explicitly set on the
+// command line.
+func flagIsSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+// Reads user input from stdin to get a map with param names and their values.
+func ReadUserInput(t *skel.Skeleton) map[string]string {
+	paramvals := make(map[string]string)
+
+	fmt.Println()
+
+	// answers given so far can be used in choices
+	t.KeyValues = paramvals
+
+	given, err := givenValues(t)
+	if err != nil {
+		fatalf("Invalid parameters: %s\n", err)
+	}
+
+	for _, p := range t.Config.Parameters {
+		def, err := t.DefaultValue(p)
+		if err != nil {
+			fatalf("%s\n", err)
+		}
+		if value, ok := given[p.Name]; ok {
+			value, err := t.CheckValue(p, skel.NormalizeInput(value, p.Normalize))
+			if err != nil {
+				fatalf("Invalid value for parameter '%s': %s\n", p.Name, err)
+			}
+			paramvals[p.Name] = value
+			continue
+		}
+		if *flagNoInput {
+			if p.Default == "" {
+				fatalf("No value given for parameter '%s' (%s), and -no-input is set.\n", p.Name, p.Description)
+			}
+			value, err := t.CheckValue(p, def)
+			if err != nil {
+				fatalf("Invalid default for parameter '%s': %s\n", p.Name, err)
+			}
+			paramvals[p.Name] = value
+			continue
+		}
+
+		paramvals[p.Name] = promptParam(t, p, def)
+	}
+
+	fmt.Printf("\nThe following parameters are specified:\n\n")
+
+	// in the order asked, so the summary reads like the questions
+	for _, p := range t.Config.Parameters {
+		fmt.Printf("%s = %s\n", p.Name, paramvals[p.Name])
+	}
+
+	fmt.Println()
+
+	return paramvals
+}
+
+// Commands besides generating, which are given as the first argument.
+var commands = map[string]func(args []string){
+	"doctor":  runDoctor,
+	"extract": runExtract,
+	"gen":     runGen,
+	"install": runInstall,
+	"list":    runList,
+	"remove":  runRemove,
+	"new":     runNew,
+	"pack":    runPack,
+	"pin":     runPin,
+	"regen":   runRegen,
+	"schema":  runSchema,
+	"undo":    runUndo,
+	"verify":  runVerify,
+	"upgrade": runUpgrade,
+	"version": runVersion,
+}
+
+// Start of this heap.
+func main() {
+	skel.Log = os.Stdout
+	if len(os.Args) > 1 {
+		if command, ok := commands[os.Args[1]]; ok {
+			handleInterrupts()
+			command(os.Args[2:])
+			exit(0)
+		}
+	}
+
+	flag.Usage = usage
+	flag.Parse()
+	if !flag.Parsed() {
+		flag.Usage()
+		exit(1)
+	}
+
+	handleInterrupts()
+	generate()
+}
+
+// Generates output from the skeleton given by -in, as configured by the flags.
+func generate() {
+	// commands like new and regen parse the flags themselves
+	skel.Verbose = *flagVerbose
+	if *flagJSON {
+		startReport()
+	}
+	if *flagSeed != 0 {
+		skel.SeedRandom(*flagSeed)
+	}
+
+	if *flagIn == "" {
+		fatalf("No skeleton specified.\n")
+	}
+	if !contains(skel.ConflictPolicies, *flagConflict) {
+		fatalf("Invalid -on-conflict '%s', expected one of: %s\n", *flagConflict, strings.Join(skel.ConflictPolicies, ", "))
+	}
+
+	fmt.Printf("Opening skeleton '%s'\n", *flagIn)
+
+	if *flagDryRun {
+		fmt.Printf("This run will not have any effect (dry-run)!\n")
+	}
+
+	// the owner is resolved up front, so a typo does not waste a run
+	ownerSpec := *flagOwner
+	if ownerSpec == "" {
+		ownerSpec = skel.LoadUserConfig().Owner
+	}
+	var owner *skel.Owner
+	if ownerSpec != "" {
+		o, err := skel.ParseOwner(ownerSpec)
+		if err != nil {
+			fatalf("Invalid owner '%s': %s\n", ownerSpec, err)
+		}
+		owner = &o
+	}
+
+	var signingKey ed25519.PrivateKey
+	if *flagAttest != "" {
+		key, err := skel.ReadSigningKey(*flagAttest)
+		if err != nil {
+			fatalf("Unable to read the signing key: %s\n", err)
+		}
+		signingKey = key
+	}
+
+	t, err := skel.Load(*flagIn)
+	if err != nil {
+		fatalf("%s\n", err)
+	}
+
+	fmt.Println()
+	fmt.Printf("%s\n", t.Config.Name)
+	fmt.Printf("%s\n\n", t.Config.Description)
+	fmt.Printf("%d configurable parameter(s) defined:\n", len(t.Config.Parameters))
+	if *flagVerbose {
+		for _, params := range t.Config.Parameters {
+			fmt.Printf("  ${%s}: %s\n", params.Name, params.Description)
+		}
+	}
+
+	if *flagTarget != "" && (flagIsSet("out") || flagIsSet("name") || *flagNoSuffix) {
+		fatalf("-target cannot be combined with -out, -name or -no-suffix.\n")
+	}
+
+	// let the user pick the output directory when none was given explicitly
+	outdir := *flagOut
+	if !flagIsSet("out") && *flagTarget == "" && isInteractive() {
+		outdir = PickOutputDir(*flagOut)
+	}
+
+	opts := skel.Options{
+		Values:     ReadUserInput(t),
+		Outdir:     outdir,
+		Name:       *flagName,
+		NoSuffix:   *flagNoSuffix,
+		Target:     *flagTarget,
+		Random:     recordedRandom,
+		DryRun:     *flagDryRun,
+		Owner:      owner,
+		Conflict:   *flagConflict,
+		SigningKey: signingKey,
+		LockWait:   *flagLockWait,
+		NoHooks:    *flagNoHooks,
+		KeepTimes:  *flagKeepTimes,
+		Rollback:   *flagRollback,
+		Generated:  recordedGenerated,
+	}
+	if isInteractive() {
+		opts.Resolve = promptConflict
+	}
+	result, err := t.Generate(opts)
+	reportResult(result)
+	if result != nil {
+		for _, w := range result.Warnings {
+			fmt.Fprintf(os.Stderr, "%s\n", w)
+		}
+	}
+	if err != nil {
+		fatalf("%s\n", err)
+	}
+	if *flagDryRun {
+		printPreview(result)
+	}
+
+	if len(result.Unsubstituted) > 0 {
+		fmt.Printf("\nWarning: the following variables were left unsubstituted:\n\n")
+		for _, k := range result.Unsubstituted {
+			fmt.Printf("\t%s\n", k)
+		}
+	}
+
+	// remove temporary directories
+	exit(0)
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/krpors/skel"
+)
+
+// Runs the new command: skel new <skeleton> [flags], which generates output
+// from a bundled skeleton. Without a skeleton, the bundled skeletons are
+// listed.
+func runNew(args []string) {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		fmt.Fprintf(os.Stderr, "Usage: %s new <skeleton> [flags]\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Generates output from an alias of the user configuration or a bundled\n")
+		fmt.Fprintf(os.Stderr, "skeleton. The flags are those of generating, except -in.\n")
+		if aliases := skel.LoadUserConfig().Aliases; len(aliases) > 0 {
+			fmt.Fprintf(os.Stderr, "\nAliases:\n\n")
+			for _, a := range aliases {
+				fmt.Fprintf(os.Stderr, "  %-12s %s\n", a.Name, a)
+			}
+		}
+		fmt.Fprintf(os.Stderr, "\nBundled skeletons:\n\n")
+		for _, name := range skel.GallerySkeletons() {
+			fmt.Fprintf(os.Stderr, "  %s\n", name)
+		}
+		exit(1)
+	}
+
+	flag.Usage = usage
+	flag.CommandLine.Parse(args[1:])
+	// aliases take precedence, so a bundled skeleton can be replaced
+	if _, ok := skel.LoadUserConfig().Alias(args[0]); ok {
+		*flagIn = skel.ALIAS_PREFIX + args[0]
+	} else {
+		*flagIn = skel.GALLERY_PREFIX + args[0]
+	}
+
+	generate()
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/krpors/skel"
+)
+
+// Runs the pack command, which bundles a skeleton into an archive to
+// distribute: skel pack <dir> -o name.zip|name.tar.gz.
+func runPack(args []string) {
+	fs := flag.NewFlagSet("pack", flag.ExitOnError)
+	out := fs.String("o", "", "the archive to write, a .zip, .tar.gz or .tgz file")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s pack <skeleton> -o name.zip|name.tar.gz\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Checks the config.xml of a skeleton directory and bundles the skeleton into\n")
+		fmt.Fprintf(os.Stderr, "an archive, which -in accepts.\n\n")
+		fs.PrintDefaults()
+	}
+	// the skeleton may be given before the flags
+	var dir string
+	if len(args) > 0 && len(args[0]) > 0 && args[0][0] != '-' {
+		dir, args = args[0], args[1:]
+	}
+	fs.Parse(args)
+	if dir == "" && fs.NArg() == 1 {
+		dir = fs.Arg(0)
+	} else if fs.NArg() > 0 {
+		dir = ""
+	}
+	if dir == "" || *out == "" {
+		fs.Usage()
+		exit(1)
+	}
+
+	files, err := skel.Pack(dir, *out)
+	if err != nil {
+		fatalf("Unable to pack '%s': %s\n", dir, err)
+	}
+	fmt.Printf("Packed %d file(s) into '%s'\n", files, *out)
+}
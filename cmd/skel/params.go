@@ -0,0 +1,81 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/krpors/skel"
+)
+
+// Values of parameters given on the command line with -param key=value,
+// which may be repeated.
+type paramValues map[string]string
+
+func (p paramValues) String() string {
+	var pairs []string
+	for k, v := range p {
+		pairs = append(pairs, k+"="+v)
+	}
+	return strings.Join(pairs, ", ")
+}
+
+func (p paramValues) Set(value string) error {
+	i := strings.Index(value, "=")
+	if i <= 0 {
+		return fmt.Errorf("expected key=value")
+	}
+	p[value[:i]] = value[i+1:]
+	return nil
+}
+
+// Defines a repeatable flag with parameter values.
+func paramFlag(name, usage string) paramValues {
+	values := make(paramValues)
+	flag.Var(values, name, usage)
+	return values
+}
+
+// Values recorded in a project which is generated again, which are used for
+// the parameters not given otherwise.
+var recordedValues map[string]string
+
+// Returns the values of parameters given up front, with -param-file and
+// -param, of which the latter take precedence, and the recorded values. Values
+// of unknown parameters are refused, so typos do not go unnoticed.
+func givenValues(t *skel.Skeleton) (map[string]string, error) {
+	given := make(map[string]string)
+	if *flagParamFile != "" {
+		answers, err := skel.ReadParamFile(*flagParamFile)
+		if err != nil {
+			return nil, fmt.Errorf("invalid parameter file '%s': %s", *flagParamFile, err)
+		}
+		for k, v := range answers {
+			given[k] = v
+		}
+	}
+	for k, v := range flagParams {
+		given[k] = v
+	}
+
+	for k := range given {
+		known := false
+		for _, p := range t.Config.Parameters {
+			known = known || p.Name == k
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown parameter '%s'", k)
+		}
+	}
+
+	// recorded answers of parameters which the skeleton no longer has are
+	// left out
+	for _, p := range t.Config.Parameters {
+		if v, ok := recordedValues[p.Name]; ok {
+			if _, ok := given[p.Name]; !ok {
+				given[p.Name] = v
+			}
+		}
+	}
+	return given, nil
+}
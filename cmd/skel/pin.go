@@ -0,0 +1,117 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/krpors/skel"
+)
+
+// Runs the pin command, which lists, adds, updates and removes aliases:
+// skel pin [-check] | skel pin [-source s] [-version v] <alias> | skel pin -remove <alias>.
+func runPin(args []string) {
+	fs := flag.NewFlagSet("pin", flag.ExitOnError)
+	source := fs.String("source", "", "directory, zip file or git repository of the alias")
+	version := fs.String("version", "", "tag or commit to pin the alias to, or 'latest' for the latest tag")
+	remove := fs.Bool("remove", false, "remove the alias")
+	check := fs.Bool("check", false, "when listing, check for newer tags of pinned aliases")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s pin [-check]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s pin [-source s] [-version v] <alias>\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s pin -remove <alias>\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Lists the skeleton aliases of the user configuration, or adds, updates or\n")
+		fmt.Fprintf(os.Stderr, "removes one. Aliases are used with '%s new <alias>'.\n\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	config := skel.LoadUserConfig()
+	if fs.NArg() == 0 {
+		listAliases(config, *check)
+		return
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		exit(1)
+	}
+
+	name := fs.Arg(0)
+	i := len(config.Aliases)
+	for j, a := range config.Aliases {
+		if a.Name == name {
+			i = j
+		}
+	}
+
+	if *remove {
+		if i == len(config.Aliases) {
+			fatalf("Unknown alias '%s'.\n", name)
+		}
+		config.Aliases = append(config.Aliases[:i], config.Aliases[i+1:]...)
+		if err := skel.SaveUserConfig(config); err != nil {
+			fatalf("Unable to save the user configuration: %s\n", err)
+		}
+		fmt.Printf("Removed alias '%s'.\n", name)
+		return
+	}
+
+	if strings.ContainsAny(name, `/\:`) || strings.HasPrefix(name, "-") {
+		fatalf("Invalid alias '%s'.\n", name)
+	}
+	if i == len(config.Aliases) {
+		if *source == "" {
+			fatalf("Unknown alias '%s', give its -source to add it.\n", name)
+		}
+		config.Aliases = append(config.Aliases, skel.SkeletonAlias{Name: name})
+	}
+	a := &config.Aliases[i]
+	if *source != "" {
+		a.Source = *source
+	}
+	if *version == "latest" {
+		latest, err := skel.LatestTag(skel.GitURL(a.Source))
+		if err != nil {
+			fatalf("Unable to list the tags of '%s': %s\n", a.Source, err)
+		}
+		if latest == "" {
+			fatalf("'%s' has no version tags.\n", a.Source)
+		}
+		*version = latest
+	}
+	if *version != "" {
+		a.Version = *version
+	}
+
+	// fetch the pinned version now, so a typo is found right away
+	if _, err := skel.ResolveAlias(*a); err != nil {
+		fatalf("Unable to fetch '%s': %s\n", a, err)
+	}
+	if err := skel.SaveUserConfig(config); err != nil {
+		fatalf("Unable to save the user configuration: %s\n", err)
+	}
+	fmt.Printf("%s -> %s\n", a.Name, a)
+}
+
+// Lists the aliases of the configuration, optionally with the latest tags of
+// their git repositories.
+func listAliases(config skel.UserConfig, check bool) {
+	if len(config.Aliases) == 0 {
+		fmt.Printf("No aliases are defined in '%s'.\n", skel.UserConfigFile())
+		return
+	}
+	for _, a := range config.Aliases {
+		line := fmt.Sprintf("%-12s %s", a.Name, a)
+		if check && a.Version != "" {
+			latest, err := skel.LatestTag(skel.GitURL(a.Source))
+			switch {
+			case err != nil:
+				line += fmt.Sprintf(" (unable to check: %s)", err)
+			case latest != "" && skel.CompareVersions(latest, a.Version) > 0:
+				line += fmt.Sprintf(" (%s is available)", latest)
+			}
+		}
+		fmt.Println(line)
+	}
+}
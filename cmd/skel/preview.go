@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/krpors/skel"
+)
+
+// Returns the size in bytes in a human readable form, like 1.5 KiB.
+func formatSize(size int64) string {
+	if size < 1024 {
+		return fmt.Sprintf("%d B", size)
+	}
+	value, unit := float64(size)/1024, "KiB"
+	for _, u := range []string{"MiB", "GiB"} {
+		if value < 1024 {
+			break
+		}
+		value, unit = value/1024, u
+	}
+	return fmt.Sprintf("%.1f %s", value, unit)
+}
+
+// Compares the entry with what exists at path. Returns its status, like "new",
+// "unchanged" or "changed", and for changed text files the unified diff.
+func previewStatus(path string, e skel.Entry) (string, string) {
+	info, err := os.Lstat(path)
+	switch {
+	case err != nil:
+		return "new", ""
+	case e.Dir && info.IsDir():
+		return "exists", ""
+	case e.Dir || info.IsDir():
+		return "replaces", ""
+	case e.Link != "":
+		if target, err := os.Readlink(path); err == nil && target == e.Link {
+			return "unchanged", ""
+		}
+		return "changed", ""
+	}
+
+	current, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "unreadable", ""
+	}
+	content, err := e.Bytes()
+	if err != nil {
+		return "unreadable", ""
+	}
+	switch {
+	case bytes.Equal(current, content):
+		return "unchanged", ""
+	case bytes.IndexByte(current, 0) >= 0 || bytes.IndexByte(content, 0) >= 0:
+		// binary files are not diffed
+		return "changed", ""
+	}
+	return "changed", skel.UnifiedDiff(path, path+" (new)", current, content)
+}
+
+// Prints what a dry run would generate: the tree of the output, with the size
+// of every file and whether it is new or changes an existing one, followed by
+// the diffs of the changed files. Plain prompts print every path in full
+// instead of indenting it.
+func printPreview(result *skel.Result) {
+	roots := []string{""}
+	for _, e := range result.Entries {
+		if !contains(roots, e.Root) {
+			roots = append(roots, e.Root)
+		}
+	}
+
+	var diffs []string
+	for _, root := range roots {
+		dir := root
+		if dir == "" {
+			dir = result.OutputDir
+		}
+		fmt.Printf("\nPreview of '%s':\n\n", dir)
+
+		for _, e := range result.Entries {
+			if e.Root != root {
+				continue
+			}
+			status, diff := previewStatus(filepath.Join(dir, e.Path), e)
+			if diff != "" {
+				diffs = append(diffs, diff)
+			}
+
+			name := filepath.ToSlash(e.Path)
+			if !plainPrompts() {
+				name = strings.Repeat("  ", strings.Count(name, "/")) + filepath.Base(e.Path)
+			}
+			switch {
+			case e.Dir:
+				fmt.Printf("  %s/ (%s)\n", name, status)
+			case e.Link != "":
+				fmt.Printf("  %s -> %s (%s)\n", name, e.Link, status)
+			default:
+				size, err := e.Size()
+				if err != nil {
+					fmt.Printf("  %s (%s)\n", name, err)
+					continue
+				}
+				fmt.Printf("  %s, %s (%s)\n", name, formatSize(size), status)
+			}
+		}
+	}
+
+	for _, diff := range diffs {
+		fmt.Printf("\n%s", diff)
+	}
+}
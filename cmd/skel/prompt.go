@@ -0,0 +1,294 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/krpors/skel"
+)
+
+// Reports whether prompts are plain: every question is asked on a single
+// line, ending in the answer, and lists are spelled out, for screen readers
+// and restricted terminals. This is the default when TERM is "dumb".
+func plainPrompts() bool {
+	return *flagPlain || os.Getenv("TERM") == "dumb"
+}
+
+// Asks the question, and returns the answer.
+func ask(question string) string {
+	if plainPrompts() {
+		fmt.Printf("%s: ", question)
+	} else {
+		fmt.Printf("%s: \n> ", question)
+	}
+	return readLine()
+}
+
+// Prints the numbered options of a question, of which def is the default, or
+// -1 for none.
+func printOptions(title string, options []string, def int) {
+	if plainPrompts() {
+		fmt.Printf("%s, %d options:\n", title, len(options))
+	} else {
+		fmt.Printf("\n%s:\n\n", title)
+	}
+	for i, o := range options {
+		switch {
+		case plainPrompts() && i == def:
+			fmt.Printf("Option %d: %s, the default.\n", i+1, o)
+		case plainPrompts():
+			fmt.Printf("Option %d: %s.\n", i+1, o)
+		case i == def:
+			fmt.Printf("  %d) %s (default)\n", i+1, o)
+		default:
+			fmt.Printf("  %d) %s\n", i+1, o)
+		}
+	}
+}
+
+// Asks for one of the options printed by printOptions, and returns the
+// answer. Plain prompts ask the question; otherwise only a marker is printed.
+func choose(question string) string {
+	if plainPrompts() {
+		fmt.Printf("%s: ", question)
+	} else {
+		fmt.Printf("> ")
+	}
+	return readLine()
+}
+
+// Asks for the value of the parameter until a valid one is given. An empty
+// answer is the default.
+func promptParam(t *skel.Skeleton, p skel.SkeletonParams, def string) string {
+	if p.Choices != nil {
+		options, err := t.ChoiceOptions(*p.Choices)
+		if err != nil {
+			fatalf("Unable to list the choices of '%s': %s\n", p.Name, err)
+		}
+		if len(options) == 0 {
+			fatalf("There are no choices for '%s'.\n", p.Name)
+		}
+		if p.List {
+			return promptChoices(t, p, options, def)
+		}
+		selected := -1
+		for i, o := range options {
+			if o == def {
+				selected = i
+			}
+		}
+		return promptChoice(p.Description, options, selected)
+	}
+
+	question := p.Description
+	switch {
+	case p.Type == skel.PARAM_BOOL:
+		question += " (yes or no)"
+	case p.List:
+		question += " (comma separated)"
+	}
+	if def != "" {
+		question += " [" + def + "]"
+	}
+	for {
+		answer := ask(question)
+		if strings.TrimSpace(answer) == "" {
+			answer = def
+		}
+		value, err := t.CheckValue(p, skel.NormalizeInput(answer, p.Normalize))
+		if err == nil {
+			return value
+		}
+		if stdinEOF {
+			fatalf("No valid value given for '%s': %s\n", p.Description, err)
+		}
+		fmt.Fprintf(os.Stderr, "Invalid value: %s\n", err)
+	}
+}
+
+// Asks for one of the options until a valid one is given, either by its
+// number or as-is. An empty answer is the option def, unless it is -1.
+func promptChoice(description string, options []string, def int) string {
+	for {
+		printOptions(description, options, def)
+
+		answer := strings.TrimSpace(choose("Number or name of the option"))
+		if answer == "" && def >= 0 {
+			return options[def]
+		}
+		if num, err := strconv.Atoi(answer); err == nil && num >= 1 && num <= len(options) {
+			return options[num-1]
+		}
+		if contains(options, answer) {
+			return answer
+		}
+		if stdinEOF {
+			fatalf("No choice given for '%s'.\n", description)
+		}
+		fmt.Fprintf(os.Stderr, "Invalid choice '%s'.\n\n", answer)
+	}
+}
+
+// Asks for any number of the options of the list parameter until valid ones
+// are given, each by its number or as-is, separated by commas. An empty answer
+// is the default.
+func promptChoices(t *skel.Skeleton, p skel.SkeletonParams, options []string, def string) string {
+	title := p.Description + " (comma separated)"
+	if def != "" {
+		title += " [" + def + "]"
+	}
+	for {
+		printOptions(title, options, -1)
+
+		answer := strings.TrimSpace(choose("Numbers or names of the options"))
+		if answer == "" {
+			answer = def
+		}
+		var items []string
+		for _, item := range strings.Split(answer, ",") {
+			item = strings.TrimSpace(item)
+			if num, err := strconv.Atoi(item); err == nil && num >= 1 && num <= len(options) {
+				item = options[num-1]
+			}
+			items = append(items, item)
+		}
+		value, err := t.CheckValue(p, strings.Join(items, ","))
+		if err == nil {
+			return value
+		}
+		if stdinEOF {
+			fatalf("No valid choices given for '%s': %s\n", p.Description, err)
+		}
+		fmt.Fprintf(os.Stderr, "Invalid choice: %s\n\n", err)
+	}
+}
+
+// Asks the user what to do with the existing file at path, which would be
+// replaced by content.
+func promptConflict(path string, content []byte) string {
+	for {
+		if plainPrompts() {
+			fmt.Printf("'%s' already exists. Type o to overwrite it, s to skip it, d to view the differences, or k to keep both. The default is to skip it: ", path)
+		} else {
+			fmt.Printf("\n'%s' already exists.\n", path)
+			fmt.Printf("[o]verwrite, [s]kip (default), view [d]iff or [k]eep both?\n> ")
+		}
+
+		switch strings.ToLower(strings.TrimSpace(readLine())) {
+		case "o", "overwrite":
+			return skel.RESOLVE_OVERWRITE
+		case "", "s", "skip":
+			return skel.RESOLVE_SKIP
+		case "k", "keep", "keep both":
+			return skel.RESOLVE_KEEP_BOTH
+		case "d", "diff":
+			current, err := ioutil.ReadFile(path)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Unable to read '%s': %s\n", path, err)
+				continue
+			}
+			diff := skel.UnifiedDiff(path, path+" (new)", current, content)
+			if diff == "" {
+				fmt.Printf("The contents are equal.\n")
+			}
+			fmt.Print(diff)
+		default:
+			fmt.Fprintf(os.Stderr, "Invalid choice.\n")
+		}
+	}
+}
+
+// Returns the current working directory, or an empty string if unknown.
+func getwd() string {
+	cwd, _ := os.Getwd()
+	return cwd
+}
+
+// Reports whether the standard input is an interactive terminal.
+func isInteractive() bool {
+	if *flagNoInput {
+		return false
+	}
+	stat, err := os.Stdin.Stat()
+	if err != nil {
+		return false
+	}
+	return stat.Mode()&os.ModeCharDevice != 0
+}
+
+// Interactively asks the user for the output directory. The choices are the
+// default output directory, the previously chosen directory, the current
+// directory, the configured workspace roots and a custom path. The chosen
+// directory is validated to be writable, and the question is repeated until a
+// valid choice is made.
+func PickOutputDir(defaultDir string) string {
+	choices := []string{defaultDir}
+	for _, c := range append([]string{skel.ReadState(skel.STATE_OUTDIR), getwd()}, skel.WorkspaceRoots()...) {
+		if c != "" && !contains(choices, c) {
+			choices = append(choices, c)
+		}
+	}
+
+	for {
+		printOptions("Output directory", append(choices, "Custom path"), 0)
+
+		answer := strings.TrimSpace(choose("Number of the option, or a path"))
+
+		var dir string
+		num, err := strconv.Atoi(answer)
+		switch {
+		case answer == "":
+			dir = defaultDir
+		case err == nil && num >= 1 && num <= len(choices):
+			dir = choices[num-1]
+		case err == nil && num == len(choices)+1:
+			dir = strings.TrimSpace(ask("Path"))
+		case err == nil:
+			if stdinEOF {
+				fatalf("No valid output directory given: invalid choice %d.\n", num)
+			}
+			fmt.Fprintf(os.Stderr, "Invalid choice %d.\n", num)
+			continue
+		default:
+			// anything else is taken as a path as-is
+			dir = answer
+		}
+
+		if dir == "" {
+			if stdinEOF {
+				fatalf("No output directory given.\n")
+			}
+			fmt.Fprintf(os.Stderr, "No path given.\n")
+			continue
+		}
+
+		if err := skel.CheckWritable(dir); err != nil {
+			if stdinEOF {
+				fatalf("Cannot use '%s' as output directory: %s\n", dir, err)
+			}
+			fmt.Fprintf(os.Stderr, "Cannot use '%s': %s\n", dir, err)
+			continue
+		}
+
+		// remember the choice for the next run, as a convenience only
+		if abs, err := filepath.Abs(dir); err == nil {
+			skel.WriteState(skel.STATE_OUTDIR, abs)
+		}
+
+		return dir
+	}
+}
+
+// Reports whether the list contains the given string.
+func contains(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+	return false
+}
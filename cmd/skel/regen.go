@@ -0,0 +1,61 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/krpors/skel"
+)
+
+// Random values of the project which is generated again, if it has a
+// manifest, so they stay the same.
+var recordedRandom *skel.ManifestRandom
+
+// Paths the earlier generation of the project created, if it has a manifest,
+// so undo still removes them after generating again.
+var recordedGenerated []string
+
+// Runs the regen command: skel regen <project> [flags], which generates the
+// project again with its recorded answers, from the skeleton it was generated
+// from, into the project itself. Only new parameters are asked for.
+func runRegen(args []string) {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		fmt.Fprintf(os.Stderr, "Usage: %s regen <project> [flags]\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Generates a project again, with the answers recorded in its %s,\n", skel.ANSWERS_FILE)
+		fmt.Fprintf(os.Stderr, "asking only for parameters which were added to the skeleton since. The\n")
+		fmt.Fprintf(os.Stderr, "skeleton is the one recorded, unless -in is given. The flags are those of\n")
+		fmt.Fprintf(os.Stderr, "generating, except -out, -name and -target.\n")
+		exit(1)
+	}
+	dir := args[0]
+
+	flag.Usage = usage
+	flag.CommandLine.Parse(args[1:])
+	if flagIsSet("out") || flagIsSet("name") || flagIsSet("target") || *flagNoSuffix {
+		fatalf("The output of regen is the project itself, -out, -name, -target and -no-suffix cannot be given.\n")
+	}
+
+	a, err := skel.ReadAnswers(dir)
+	if err != nil {
+		fatalf("Unable to read the answers of '%s': %s\n", dir, err)
+	}
+	if *flagIn == "" {
+		*flagIn = a.Skeleton.Source
+	}
+	if m, err := skel.ReadManifest(dir); err == nil {
+		recordedRandom = &m.Random
+		recordedGenerated = m.Generated()
+	}
+	recordedValues = a.Answers
+	*flagTarget = dir
+
+	// the project exists, so every file conflicts; ask what to do with
+	// them, unless told otherwise
+	if !flagIsSet("on-conflict") && isInteractive() {
+		*flagConflict = skel.CONFLICT_PROMPT
+	}
+
+	generate()
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/krpors/skel"
+)
+
+// Runs the list command, which lists the installed skeletons with their
+// parameters: skel list.
+func runList(args []string) {
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s list\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Lists the skeletons installed in '%s'.\n", skel.RegistryDir())
+	}
+	fs.Parse(args)
+	if fs.NArg() != 0 {
+		fs.Usage()
+		exit(1)
+	}
+
+	installed, err := skel.InstalledSkeletons()
+	if err != nil {
+		fatalf("Unable to list the installed skeletons: %s\n", err)
+	}
+	if len(installed) == 0 {
+		fmt.Printf("No skeletons installed in '%s'.\n", skel.RegistryDir())
+		return
+	}
+	for i, s := range installed {
+		if i > 0 {
+			fmt.Println()
+		}
+		title := s.Config.Name
+		if s.Config.Version != "" {
+			title += " " + s.Config.Version
+		}
+		fmt.Printf("%s: %s\n", s.Name, title)
+		if d := strings.TrimSpace(s.Config.Description); d != "" {
+			fmt.Printf("  %s\n", d)
+		}
+		for _, p := range s.Config.Parameters {
+			fmt.Printf("  ${%s}: %s\n", p.Name, p.Description)
+		}
+	}
+}
+
+// Runs the install command, which adds a skeleton to the registry:
+// skel install [-name n] [-replace] <skeleton>.
+func runInstall(args []string) {
+	fs := flag.NewFlagSet("install", flag.ExitOnError)
+	name := fs.String("name", "", "name to install the skeleton under (default from the skeleton)")
+	replace := fs.Bool("replace", false, "replace an installed skeleton of the same name")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s install [-name n] [-replace] <skeleton>\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Installs a skeleton directory, zip file, git repository or URL in\n")
+		fmt.Fprintf(os.Stderr, "'%s', to generate from with '%s gen <name>'.\n\n", skel.RegistryDir(), os.Args[0])
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		exit(1)
+	}
+
+	installed, err := skel.Install(fs.Arg(0), *name, *replace)
+	if err != nil {
+		fatalf("Unable to install '%s': %s\n", fs.Arg(0), err)
+	}
+	fmt.Printf("Installed '%s' as '%s'.\n", fs.Arg(0), installed)
+}
+
+// Runs the remove command, which removes a skeleton from the registry:
+// skel remove <name>.
+func runRemove(args []string) {
+	if len(args) != 1 || strings.HasPrefix(args[0], "-") {
+		fmt.Fprintf(os.Stderr, "Usage: %s remove <name>\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Removes an installed skeleton.\n")
+		exit(1)
+	}
+	if err := skel.Uninstall(args[0]); err != nil {
+		fatalf("Unable to remove '%s': %s\n", args[0], err)
+	}
+	fmt.Printf("Removed '%s'.\n", args[0])
+}
+
+// Runs the gen command: skel gen <name> [flags], which generates output from
+// an installed skeleton.
+func runGen(args []string) {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		fmt.Fprintf(os.Stderr, "Usage: %s gen <name> [flags]\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Generates output from an installed skeleton, as listed by '%s list'.\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "The flags are those of generating, except -in.\n")
+		exit(1)
+	}
+
+	flag.Usage = usage
+	flag.CommandLine.Parse(args[1:])
+	*flagIn = skel.INSTALLED_PREFIX + args[0]
+
+	generate()
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/krpors/skel"
+)
+
+// The report of generating, printed with -json for CI and wrapper tools.
+type Report struct {
+	Success       bool     `json:"success"`
+	Skeleton      string   `json:"skeleton"`
+	OutputDir     string   `json:"outputDir,omitempty"`
+	DryRun        bool     `json:"dryRun"`
+	Created       []string `json:"created"`
+	Unsubstituted []string `json:"unsubstituted"`
+	Warnings      []string `json:"warnings"`
+	Errors        []string `json:"errors"`
+	RolledBack    bool     `json:"rolledBack"`
+}
+
+// The report of this run, when -json is given.
+var report *Report
+
+// Starts reporting as JSON: the report is printed on the standard output when
+// skel exits, also when it fails, so everything else is printed on the
+// standard error instead.
+func startReport() {
+	report = &Report{
+		Skeleton:      *flagIn,
+		DryRun:        *flagDryRun,
+		Created:       []string{},
+		Unsubstituted: []string{},
+		Warnings:      []string{},
+		Errors:        []string{},
+	}
+	stdout := os.Stdout
+	os.Stdout = os.Stderr
+	skel.Log = os.Stderr
+	atExit(func() {
+		report.Success = len(report.Errors) == 0
+		data, _ := json.MarshalIndent(report, "", "  ")
+		fmt.Fprintln(stdout, string(data))
+	})
+}
+
+// Adds the result of generating to the report, if any.
+func reportResult(result *skel.Result) {
+	if report == nil || result == nil {
+		return
+	}
+	report.OutputDir = result.OutputDir
+	report.Created = append(report.Created, result.Created...)
+	report.Unsubstituted = append(report.Unsubstituted, result.Unsubstituted...)
+	report.Warnings = append(report.Warnings, result.Warnings...)
+	report.Errors = append(report.Errors, result.Errors...)
+	report.RolledBack = result.RolledBack
+}
+
+// Adds the message of a fatal error to the report, unless the errors are
+// reported already.
+func reportFatal(msg string) {
+	if report != nil && len(report.Errors) == 0 {
+		report.Errors = append(report.Errors, strings.TrimSpace(msg))
+	}
+}
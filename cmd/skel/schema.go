@@ -0,0 +1,29 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/krpors/skel"
+)
+
+// Runs the schema command: skel schema <skeleton>.
+func runSchema(args []string) {
+	if len(args) != 1 {
+		fmt.Fprintf(os.Stderr, "Usage: %s schema <skeleton>\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Prints the JSON Schema of the parameters of the skeleton.\n")
+		exit(1)
+	}
+
+	t, err := skel.Load(args[0])
+	if err != nil {
+		fatalf("%s\n", err)
+	}
+
+	data, err := json.MarshalIndent(t.Schema(), "", "  ")
+	if err != nil {
+		fatalf("%s\n", err)
+	}
+	fmt.Println(string(data))
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/krpors/skel"
+)
+
+// Runs the undo command: skel undo [-dry] [-keep-modified] <project>.
+func runUndo(args []string) {
+	fs := flag.NewFlagSet("undo", flag.ExitOnError)
+	dry := fs.Bool("dry", false, "only list what would be removed")
+	keepModified := fs.Bool("keep-modified", false, "remove the unmodified files even when others were modified, keeping those")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s undo [-dry] [-keep-modified] <project directory>\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Removes the files and directories created by the most recent generation\n")
+		fmt.Fprintf(os.Stderr, "into the project, as recorded in its %s. Files modified since are\n", skel.MANIFEST_FILE)
+		fmt.Fprintf(os.Stderr, "never removed; by default nothing is removed when there are any.\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		exit(1)
+	}
+	dir := filepath.Clean(fs.Arg(0))
+
+	m, err := skel.ReadManifest(dir)
+	if err != nil {
+		fatalf("Unable to read the manifest of '%s': %s\n", dir, err)
+	}
+
+	// no generation may write into the project while it is undone
+	lock, err := skel.LockDir(dir, *flagLockWait)
+	if err != nil {
+		fatalf("Unable to lock output directory: %s\n", err)
+	}
+	atExit(func() { lock.Unlock() })
+
+	plan, err := skel.PlanUndo(dir, m)
+	if err != nil {
+		fatalf("Unable to undo '%s': %s\n", dir, err)
+	}
+	if len(plan.Modified) > 0 {
+		fmt.Fprintf(os.Stderr, "The following files were modified since they were generated:\n\n")
+		for _, rel := range plan.Modified {
+			fmt.Fprintf(os.Stderr, "\t%s\n", rel)
+		}
+		fmt.Fprintf(os.Stderr, "\n")
+		if !*keepModified && !*dry {
+			fatalf("Refusing to undo '%s'; use -keep-modified to remove the other files.\n", dir)
+		}
+	}
+
+	if err := plan.Apply(dir, m, *dry); err != nil {
+		fatalf("Unable to undo '%s': %s\n", dir, err)
+	}
+}
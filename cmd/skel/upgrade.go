@@ -0,0 +1,240 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/krpors/skel"
+)
+
+const (
+	// URL of the latest release in the GitHub API.
+	RELEASES_URL = "https://api.github.com/repos/krpors/skel/releases/latest"
+
+	// Name of the release asset with the sha256 checksums of all binaries,
+	// and of its detached ed25519 signature.
+	CHECKSUMS_ASSET = "checksums.txt"
+	SIGNATURE_ASSET = "checksums.txt.sig"
+)
+
+// Base64 encoded ed25519 public key with which release checksums are
+// signed. Set at build time with -ldflags "-X main.releasePublicKey=...".
+// When empty, releases cannot be verified, and upgrade refuses to replace the
+// binary unless -insecure is given.
+var releasePublicKey string
+
+// A release, as returned by the GitHub API.
+type Release struct {
+	TagName string         `json:"tag_name"`
+	Assets  []ReleaseAsset `json:"assets"`
+}
+
+// A downloadable file of a release.
+type ReleaseAsset struct {
+	Name string `json:"name"`
+	URL  string `json:"browser_download_url"`
+}
+
+// Returns the download URL of the asset with the given name, or an empty
+// string if the release has no such asset.
+func (r Release) assetURL(name string) string {
+	for _, a := range r.Assets {
+		if a.Name == name {
+			return a.URL
+		}
+	}
+	return ""
+}
+
+// Returns the name of the release binary for this platform.
+func binaryAssetName() string {
+	name := fmt.Sprintf("skel_%s_%s", runtime.GOOS, runtime.GOARCH)
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	return name
+}
+
+var httpClient = &http.Client{Timeout: 5 * time.Minute}
+
+// Downloads the contents of the given URL.
+func download(url string) ([]byte, error) {
+	resp, err := httpClient.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
+
+// Returns the checksum of the named file from a sha256sum style listing.
+func findChecksum(checksums []byte, name string) (string, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(checksums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == name {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+	return "", fmt.Errorf("no checksum for '%s' in %s", name, CHECKSUMS_ASSET)
+}
+
+// Verifies the detached signature of the checksums with the release public
+// key.
+func verifySignature(checksums, signature []byte) error {
+	key, err := base64.StdEncoding.DecodeString(releasePublicKey)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return fmt.Errorf("invalid release public key")
+	}
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(signature)))
+	if err != nil {
+		return fmt.Errorf("invalid signature: %s", err)
+	}
+	if !ed25519.Verify(ed25519.PublicKey(key), checksums, sig) {
+		return fmt.Errorf("signature of %s does not match", CHECKSUMS_ASSET)
+	}
+	return nil
+}
+
+// Replaces the binary at exe with the given contents. The new binary is
+// written next to it first and then renamed, so a failure never leaves a
+// partially written binary behind.
+func replaceBinary(exe string, contents []byte) error {
+	info, err := os.Stat(exe)
+	if err != nil {
+		return err
+	}
+
+	tmp := exe + ".new"
+	if err := ioutil.WriteFile(tmp, contents, info.Mode().Perm()|0111); err != nil {
+		return err
+	}
+
+	// a running executable cannot be overwritten on Windows, but it can be
+	// renamed out of the way
+	if runtime.GOOS == "windows" {
+		old := exe + ".old"
+		os.Remove(old)
+		if err := os.Rename(exe, old); err != nil {
+			os.Remove(tmp)
+			return err
+		}
+	}
+
+	if err := os.Rename(tmp, exe); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// Runs the upgrade command: skel upgrade [-check].
+func runUpgrade(args []string) {
+	fs := flag.NewFlagSet("upgrade", flag.ExitOnError)
+	check := fs.Bool("check", false, "only check whether a newer version is available")
+	url := fs.String("url", RELEASES_URL, "URL of the latest release in the GitHub API")
+	insecure := fs.Bool("insecure", false, "replace the binary without a release public key, verifying only the unsigned checksums")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s upgrade [-check] [-insecure]\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Replaces this binary with the latest release, after verifying the signature\n")
+		fmt.Fprintf(os.Stderr, "of its checksums with the release public key skel was built with.\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	data, err := download(*url)
+	if err != nil {
+		fatalf("Unable to check for the latest release: %s\n", err)
+	}
+	release := Release{}
+	if err := json.Unmarshal(data, &release); err != nil {
+		fatalf("Invalid release information: %s\n", err)
+	}
+
+	if skel.CompareVersions(release.TagName, skel.VERSION) <= 0 {
+		fmt.Printf("skel v%s is up to date (latest release is %s).\n", skel.VERSION, release.TagName)
+		return
+	}
+	fmt.Printf("A newer version is available: %s (this is v%s).\n", release.TagName, skel.VERSION)
+	if *check {
+		return
+	}
+
+	// checksums from the same release prove nothing about where the binary
+	// comes from, only a signature does
+	if releasePublicKey == "" {
+		if !*insecure {
+			fatalf("This build of skel has no release public key, so the release cannot be verified.\nInstall the release manually, or give -insecure to upgrade anyway.\n")
+		}
+		fmt.Fprintf(os.Stderr, "Warning: upgrading without verifying the release, only checksums from the same release are checked.\n")
+	}
+
+	name := binaryAssetName()
+	binaryURL := release.assetURL(name)
+	checksumsURL := release.assetURL(CHECKSUMS_ASSET)
+	if binaryURL == "" || checksumsURL == "" {
+		fatalf("Release %s has no '%s' or '%s'\n", release.TagName, name, CHECKSUMS_ASSET)
+	}
+
+	checksums, err := download(checksumsURL)
+	if err != nil {
+		fatalf("Unable to download %s: %s\n", CHECKSUMS_ASSET, err)
+	}
+	if releasePublicKey != "" {
+		signatureURL := release.assetURL(SIGNATURE_ASSET)
+		if signatureURL == "" {
+			fatalf("Release %s is not signed\n", release.TagName)
+		}
+		signature, err := download(signatureURL)
+		if err != nil {
+			fatalf("Unable to download %s: %s\n", SIGNATURE_ASSET, err)
+		}
+		if err := verifySignature(checksums, signature); err != nil {
+			fatalf("%s\n", err)
+		}
+	}
+
+	expected, err := findChecksum(checksums, name)
+	if err != nil {
+		fatalf("%s\n", err)
+	}
+
+	fmt.Printf("Downloading %s\n", binaryURL)
+	binary, err := download(binaryURL)
+	if err != nil {
+		fatalf("Unable to download %s: %s\n", name, err)
+	}
+	if actual := fmt.Sprintf("%x", sha256.Sum256(binary)); actual != expected {
+		fatalf("Checksum mismatch for %s: expected %s, got %s\n", name, expected, actual)
+	}
+
+	exe, err := os.Executable()
+	if err == nil {
+		exe, err = filepath.EvalSymlinks(exe)
+	}
+	if err != nil {
+		fatalf("Unable to locate the running binary: %s\n", err)
+	}
+
+	if err := replaceBinary(exe, binary); err != nil {
+		fatalf("Unable to replace '%s': %s\n", exe, err)
+	}
+	fmt.Printf("Upgraded '%s' to %s.\n", exe, release.TagName)
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/krpors/skel"
+)
+
+const (
+	// Exit code of the verify command when the project has drifted.
+	EXIT_DRIFT = 2
+)
+
+// Runs the verify command: skel verify [-in skeleton] <project>.
+func runVerify(args []string) {
+	fs := flag.NewFlagSet("verify", flag.ExitOnError)
+	in := fs.String("in", "", "skeleton directory or zip file (default: the source recorded in the project)")
+	key := fs.String("key", "", "PEM file with the ed25519 public key with which to verify the provenance attestation")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s verify [-in skeleton] [-key public.pem] <project directory>\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Renders the skeleton again with the answers recorded in the project's\n")
+		fmt.Fprintf(os.Stderr, "%s, and reports the files which were modified, deleted or added.\n", skel.MANIFEST_FILE)
+		fmt.Fprintf(os.Stderr, "Exits with code %d when the project has drifted from its skeleton.\n\n", EXIT_DRIFT)
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		exit(1)
+	}
+	dir := fs.Arg(0)
+
+	m, err := skel.ReadManifest(dir)
+	if err != nil {
+		fatalf("Unable to read the manifest of '%s': %s\n", dir, err)
+	}
+
+	source := *in
+	if source == "" {
+		source = m.Skeleton.Source
+	}
+
+	t, err := skel.Load(source)
+	if err != nil {
+		fatalf("%s\n", err)
+	}
+
+	drift, err := t.Verify(dir, m)
+	if err != nil {
+		fatalf("Unable to verify '%s': %s\n", dir, err)
+	}
+
+	if *key != "" {
+		a, err := skel.VerifyAttestation(dir, *key)
+		if err != nil {
+			fatalf("Invalid attestation of '%s': %s\n", dir, err)
+		}
+		skeleton := strings.TrimSpace(a.Skeleton.Name + " " + a.Skeleton.Version)
+		fmt.Printf("Attested: generated by skel v%s from '%s' at %s\n", a.SkelVersion, skeleton, a.Timestamp)
+		if d, err := skel.SkeletonDigest(t.Location); err != nil || d != a.Skeleton.Digest {
+			drift = append(drift, skel.Drift{Kind: "skeleton", Path: source})
+		}
+	}
+
+	for _, d := range drift {
+		fmt.Printf("%-8s  %s\n", d.Kind, d.Path)
+	}
+	if len(drift) > 0 {
+		exit(EXIT_DRIFT)
+	}
+	fmt.Printf("'%s' matches skeleton '%s'\n", dir, t.Config.Name)
+}
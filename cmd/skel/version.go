@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"strings"
+
+	"github.com/krpors/skel"
+)
+
+// Build information, set at build time with, for example:
+//
+//	go build -ldflags "-X main.gitCommit=$(git rev-parse HEAD) -X main.buildDate=$(date -u +%FT%TZ)"
+//
+// When not set, the VCS information recorded by the Go toolchain is used.
+var (
+	gitCommit string
+	buildDate string
+)
+
+// Version information of skel.
+type VersionInfo struct {
+	Version         string   `json:"version"`
+	GitCommit       string   `json:"gitCommit"`
+	BuildDate       string   `json:"buildDate"`
+	GoVersion       string   `json:"goVersion"`
+	Platform        string   `json:"platform"`
+	SkeletonFormats []string `json:"skeletonFormats"`
+}
+
+// Returns the version information of this binary.
+func GetVersionInfo() VersionInfo {
+	info := VersionInfo{
+		Version:         skel.VERSION,
+		GitCommit:       gitCommit,
+		BuildDate:       buildDate,
+		GoVersion:       runtime.Version(),
+		Platform:        runtime.GOOS + "/" + runtime.GOARCH,
+		SkeletonFormats: skel.SupportedFormats,
+	}
+
+	if build, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range build.Settings {
+			switch {
+			case setting.Key == "vcs.revision" && info.GitCommit == "":
+				info.GitCommit = setting.Value
+			case setting.Key == "vcs.time" && info.BuildDate == "":
+				info.BuildDate = setting.Value
+			}
+		}
+	}
+	if info.GitCommit == "" {
+		info.GitCommit = "unknown"
+	}
+	if info.BuildDate == "" {
+		info.BuildDate = "unknown"
+	}
+
+	return info
+}
+
+// Runs the version command: skel version [-json].
+func runVersion(args []string) {
+	fs := flag.NewFlagSet("version", flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "print the version information as JSON")
+	fs.Parse(args)
+
+	info := GetVersionInfo()
+	if *asJSON {
+		data, _ := json.MarshalIndent(info, "", "  ")
+		fmt.Println(string(data))
+		return
+	}
+
+	fmt.Printf("skel v%s\n", info.Version)
+	fmt.Printf("  commit:           %s\n", info.GitCommit)
+	fmt.Printf("  built:            %s\n", info.BuildDate)
+	fmt.Printf("  go:               %s (%s)\n", info.GoVersion, info.Platform)
+	fmt.Printf("  skeleton formats: %s\n", strings.Join(info.SkeletonFormats, ", "))
+}
@@ -0,0 +1,151 @@
+package skel
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// Ways of resolving a file which exists already in the output.
+const (
+	RESOLVE_OVERWRITE = "overwrite" // replace the existing file
+	RESOLVE_SKIP      = "skip"      // keep the existing file, do not write the new one
+	RESOLVE_KEEP_BOTH = "keep-both" // keep the existing file, write the new one next to it
+)
+
+// Policies for files which exist already in the output.
+const (
+	CONFLICT_FAIL      = "fail"      // stop with an error
+	CONFLICT_OVERWRITE = "overwrite" // replace all of them
+	CONFLICT_SKIP      = "skip"      // keep all of them, writing only the new files
+	CONFLICT_PROMPT    = "prompt"    // ask what to do with every one of them
+)
+
+// The policies for files which exist already, as accepted by Generate.
+var ConflictPolicies = []string{CONFLICT_FAIL, CONFLICT_OVERWRITE, CONFLICT_SKIP, CONFLICT_PROMPT}
+
+// Decides what to do with the file at path which exists already, and would be
+// replaced by content. Returns one of the RESOLVE_ constants.
+type ResolveFunc func(path string, content []byte) string
+
+// Returns the files among the entries which exist already in root, and differ
+// from the entry. Files of skel itself are not regarded as conflicts, they are
+// always replaced, and neither are identical files, like when generating
+// again.
+func conflicts(root string, entries []Entry) []Entry {
+	var existing []Entry
+	for _, e := range entries {
+		if e.Dir || isSkelFile(e.Path) {
+			continue
+		}
+		path := filepath.Join(root, e.Path)
+		if _, err := os.Lstat(path); err == nil && !identical(path, e) {
+			existing = append(existing, e)
+		}
+	}
+	return existing
+}
+
+// Reports whether the file or link at path has the contents of the entry.
+func identical(path string, e Entry) bool {
+	if e.Link != "" {
+		target, err := os.Readlink(path)
+		return err == nil && target == e.Link
+	}
+	if info, err := os.Lstat(path); err != nil || !info.Mode().IsRegular() {
+		return false
+	}
+	sum, err := fileDigest(path)
+	return err == nil && sum == e.contentDigest()
+}
+
+// Decides what to do with the entries which exist already in root, according
+// to the policy, asking resolve for every one of them when prompting. Returns
+// the resolution of every conflicting path.
+func resolveConflicts(root string, entries []Entry, policy string, resolve ResolveFunc) (map[string]string, error) {
+	resolutions := make(map[string]string)
+	existing := conflicts(root, entries)
+	if len(existing) == 0 {
+		return resolutions, nil
+	}
+
+	switch policy {
+	case CONFLICT_OVERWRITE, CONFLICT_SKIP:
+		resolution := RESOLVE_OVERWRITE
+		if policy == CONFLICT_SKIP {
+			resolution = RESOLVE_SKIP
+		}
+		for _, e := range existing {
+			resolutions[e.Path] = resolution
+		}
+		return resolutions, nil
+	case CONFLICT_FAIL:
+		return nil, fmt.Errorf("'%s' already exists", filepath.Join(root, existing[0].Path))
+	}
+	if resolve == nil {
+		return nil, fmt.Errorf("'%s' already exists, and there is no way to ask what to do", filepath.Join(root, existing[0].Path))
+	}
+
+	for _, e := range existing {
+		path := filepath.Join(root, e.Path)
+		content, err := e.Bytes()
+		if err != nil {
+			return nil, err
+		}
+		resolutions[e.Path] = resolve(path, content)
+	}
+	return resolutions, nil
+}
+
+// Returns the path at which the new version of the existing file at path is
+// kept: path.new, or path.new2 and so on when that exists as well.
+func keepBothPath(path string) string {
+	for i := 1; ; i++ {
+		candidate := path + ".new"
+		if i > 1 {
+			candidate += strconv.Itoa(i)
+		}
+		if _, err := os.Lstat(candidate); os.IsNotExist(err) {
+			return candidate
+		}
+	}
+}
+
+// Moves the entries staged in the staging directory into root, which may
+// exist already. Existing files are handled as given by the resolutions. The
+// changes are recorded in the journal. Moving goes on after an error, so all
+// of them are reported.
+func mergeTree(staging, root string, entries []Entry, resolutions map[string]string, j *journal) error {
+	if err := j.mkdirAll(root, 0755); err != nil {
+		return err
+	}
+	var errs Errors
+	for _, e := range entries {
+		target := filepath.Join(root, e.Path)
+		err := checkParents(root, target)
+		switch {
+		case err != nil:
+		case e.Dir:
+			err = j.mkdirAll(target, e.perm())
+		case resolutions[e.Path] == RESOLVE_SKIP:
+			debugf("Skipping file:  %s\n", target)
+		case resolutions[e.Path] == RESOLVE_KEEP_BOTH:
+			target = keepBothPath(target)
+			if err = j.place(target); err == nil {
+				err = moveFile(filepath.Join(staging, e.Path), target)
+			}
+		default:
+			if err = j.place(target); err == nil {
+				err = moveFile(filepath.Join(staging, e.Path), target)
+			}
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if errs != nil {
+		return errs
+	}
+	return nil
+}
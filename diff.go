@@ -0,0 +1,170 @@
+package skel
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Number of unchanged lines shown around each change in a diff.
+const DIFF_CONTEXT = 3
+
+// Size of the table of the longest common subsequence, the product of the
+// numbers of changed lines of both texts, above which the changed part is not
+// diffed line by line. At this size the table takes about 8 MB.
+const MAX_DIFF_CELLS = 1 << 20
+
+// Splits the text into lines, keeping the line terminators.
+func splitLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// A single line of a diff: ' ' for unchanged, '-' for removed or '+' for
+// added lines.
+type diffLine struct {
+	op   byte
+	text string
+}
+
+// Returns the line by line differences between a and b, based on their
+// longest common subsequence. Lines the texts start and end with alike are
+// left out of it; when the table of the rest of them would be larger than
+// MAX_DIFF_CELLS, it is shown as removed and added as a whole.
+func diffLines(a, b []string) []diffLine {
+	var head, tail []diffLine
+	for len(a) > 0 && len(b) > 0 && a[0] == b[0] {
+		head = append(head, diffLine{' ', a[0]})
+		a, b = a[1:], b[1:]
+	}
+	for len(a) > 0 && len(b) > 0 && a[len(a)-1] == b[len(b)-1] {
+		tail = append(tail, diffLine{' ', a[len(a)-1]})
+		a, b = a[:len(a)-1], b[:len(b)-1]
+	}
+	for i, j := 0, len(tail)-1; i < j; i, j = i+1, j-1 {
+		tail[i], tail[j] = tail[j], tail[i]
+	}
+
+	lines := head
+	if len(b) > 0 && len(a) > MAX_DIFF_CELLS/len(b) {
+		for _, l := range a {
+			lines = append(lines, diffLine{'-', l})
+		}
+		for _, l := range b {
+			lines = append(lines, diffLine{'+', l})
+		}
+		return append(lines, tail...)
+	}
+
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			lines = append(lines, diffLine{' ', a[i]})
+			i++
+			j++
+		case j == len(b) || (i < len(a) && lcs[i+1][j] >= lcs[i][j+1]):
+			lines = append(lines, diffLine{'-', a[i]})
+			i++
+		default:
+			lines = append(lines, diffLine{'+', b[j]})
+			j++
+		}
+	}
+	return append(lines, tail...)
+}
+
+// Returns a unified diff from a to b, labeled with the given names, or an
+// empty string when they are equal.
+func UnifiedDiff(nameA, nameB string, a, b []byte) string {
+	lines := diffLines(splitLines(string(a)), splitLines(string(b)))
+
+	var sb strings.Builder
+	// positions in a and b of the current line, starting at 1
+	posA, posB := 1, 1
+	for start := 0; start < len(lines); {
+		if lines[start].op == ' ' {
+			start++
+			posA++
+			posB++
+			continue
+		}
+
+		// a hunk spans changes which are less than twice the context apart
+		end := start
+		for k := start; k < len(lines) && k-end <= 2*DIFF_CONTEXT; k++ {
+			if lines[k].op != ' ' {
+				end = k + 1
+			}
+		}
+		from := start - DIFF_CONTEXT
+		if from < 0 {
+			from = 0
+		}
+		to := end + DIFF_CONTEXT
+		if to > len(lines) {
+			to = len(lines)
+		}
+
+		hunkA, hunkB := posA-(start-from), posB-(start-from)
+		countA, countB := 0, 0
+		var body strings.Builder
+		for _, l := range lines[from:to] {
+			if l.op != '+' {
+				countA++
+			}
+			if l.op != '-' {
+				countB++
+			}
+			body.WriteByte(l.op)
+			body.WriteString(l.text)
+			if !strings.HasSuffix(l.text, "\n") {
+				body.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+
+		// an empty range starts at the line before it
+		if countA == 0 {
+			hunkA--
+		}
+		if countB == 0 {
+			hunkB--
+		}
+
+		if sb.Len() == 0 {
+			fmt.Fprintf(&sb, "--- %s\n+++ %s\n", nameA, nameB)
+		}
+		fmt.Fprintf(&sb, "@@ -%d,%d +%d,%d @@\n", hunkA, countA, hunkB, countB)
+		sb.WriteString(body.String())
+
+		for _, l := range lines[start:to] {
+			if l.op != '+' {
+				posA++
+			}
+			if l.op != '-' {
+				posB++
+			}
+		}
+		start = to
+	}
+	return sb.String()
+}
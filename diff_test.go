@@ -0,0 +1,42 @@
+package skel
+
+import (
+	"fmt"
+	"testing"
+)
+
+// Counts the lines of the diff by their operation.
+func countOps(lines []diffLine) map[byte]int {
+	ops := make(map[byte]int)
+	for _, l := range lines {
+		ops[l.op]++
+	}
+	return ops
+}
+
+func TestDiffLines(t *testing.T) {
+	a := []string{"a\n", "b\n", "c\n", "d\n"}
+	b := []string{"a\n", "x\n", "c\n", "d\n", "e\n"}
+	ops := countOps(diffLines(a, b))
+	if ops[' '] != 3 || ops['-'] != 1 || ops['+'] != 2 {
+		t.Errorf("diffLines(%q, %q) = %v, want 3 unchanged, 1 removed and 2 added lines", a, b, ops)
+	}
+}
+
+func TestDiffLinesLarge(t *testing.T) {
+	// the first and last lines differ, so the whole of both is changed
+	var a, b []string
+	for i := 0; i < 1100; i++ {
+		a = append(a, fmt.Sprintf("line %d\n", i))
+	}
+	b = append([]string{"first\n"}, a...)
+	a = append(a, "last\n")
+	if len(a)*len(b) <= MAX_DIFF_CELLS {
+		t.Fatalf("the texts are too small to exceed MAX_DIFF_CELLS")
+	}
+
+	ops := countOps(diffLines(a, b))
+	if ops[' '] != 0 || ops['-'] != len(a) || ops['+'] != len(b) {
+		t.Errorf("diffLines of %d and %d lines = %v, want all lines removed and added", len(a), len(b), ops)
+	}
+}
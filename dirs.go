@@ -0,0 +1,123 @@
+package skel
+
+import (
+	"encoding/xml"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Returns the directory named by the XDG environment variable, or the given
+// fallback when it is not set. The returned path includes the "skel"
+// subdirectory.
+func xdgDir(env string, fallback func() (string, error)) string {
+	if dir := os.Getenv(env); filepath.IsAbs(dir) {
+		return filepath.Join(dir, "skel")
+	}
+	dir, err := fallback()
+	if err != nil {
+		// no home directory at all, resort to the temp directory
+		dir = filepath.Join(os.TempDir(), "skel-"+env)
+	}
+	return filepath.Join(dir, "skel")
+}
+
+// Returns the directory holding the user configuration: $XDG_CONFIG_HOME/skel,
+// %AppData%\skel on Windows or ~/Library/Application Support/skel on macOS.
+func configDir() string {
+	return xdgDir("XDG_CONFIG_HOME", os.UserConfigDir)
+}
+
+// Returns the directory for cached data, like downloaded skeletons:
+// $XDG_CACHE_HOME/skel, %LocalAppData%\skel on Windows or
+// ~/Library/Caches/skel on macOS.
+func cacheDir() string {
+	return xdgDir("XDG_CACHE_HOME", os.UserCacheDir)
+}
+
+// Returns the directory for state which is kept between runs, like previous
+// answers: $XDG_STATE_HOME/skel, defaulting to ~/.local/state/skel. Windows
+// and macOS have no such notion, and their cache directories may be cleared,
+// so the default is the same there.
+func stateDir() string {
+	return xdgDir("XDG_STATE_HOME", func() (string, error) {
+		home, err := os.UserHomeDir()
+		return filepath.Join(home, ".local", "state"), err
+	})
+}
+
+// Expands a leading ~ in the path to the home directory.
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") && !strings.HasPrefix(path, `~\`) {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, path[1:])
+}
+
+// Returns the path of the user configuration file, config.xml in the
+// configuration directory.
+func UserConfigFile() string {
+	return filepath.Join(configDir(), "config.xml")
+}
+
+// User configuration, read from config.xml in the configuration directory.
+type UserConfig struct {
+	XMLName    xml.Name
+	Workspaces []string        `xml:"workspaces>root"`    // workspace roots offered as output directory
+	Owner      string          `xml:"owner,omitempty"`    // owner of generated files, when -owner is not given
+	Aliases    []SkeletonAlias `xml:"aliases>alias"`      // names for skeletons, used by skel new
+	Registry   string          `xml:"registry,omitempty"` // directory of installed skeletons, when $SKEL_HOME is not set
+}
+
+// Reads the user configuration. A missing or invalid configuration file
+// results in an empty configuration.
+func LoadUserConfig() UserConfig {
+	config := UserConfig{}
+
+	data, err := ioutil.ReadFile(UserConfigFile())
+	if err != nil {
+		return config
+	}
+	xml.Unmarshal(data, &config)
+
+	return config
+}
+
+// Writes the user configuration. Comments and unknown elements of the
+// existing file are not preserved.
+func SaveUserConfig(config UserConfig) error {
+	if config.XMLName.Local == "" {
+		config.XMLName.Local = "config"
+	}
+	data, err := xml.MarshalIndent(config, "", "    ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(configDir(), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(UserConfigFile(), append(data, '\n'), 0644)
+}
+
+// Reads a single value stored in the state directory, or returns an empty
+// string if it was never stored.
+func ReadState(name string) string {
+	data, err := ioutil.ReadFile(filepath.Join(stateDir(), name))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// Stores a single value in the state directory.
+func WriteState(name, value string) error {
+	if err := os.MkdirAll(stateDir(), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(stateDir(), name), []byte(value+"\n"), 0644)
+}
@@ -0,0 +1,390 @@
+package skel
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// A single finding of CheckEnvironment or CheckSkeleton.
+type Finding struct {
+	Severity string // "ok", "warn" or "fail"
+	Message  string
+	Fix      string // how to fix the problem, if any
+}
+
+// Collects the findings of the checks.
+type doctor struct {
+	findings []Finding
+}
+
+func (d *doctor) ok(format string, args ...interface{}) {
+	d.findings = append(d.findings, Finding{"ok", fmt.Sprintf(format, args...), ""})
+}
+
+func (d *doctor) warn(fix, format string, args ...interface{}) {
+	d.findings = append(d.findings, Finding{"warn", fmt.Sprintf(format, args...), fix})
+}
+
+func (d *doctor) fail(fix, format string, args ...interface{}) {
+	d.findings = append(d.findings, Finding{"fail", fmt.Sprintf(format, args...), fix})
+}
+
+// Reports whether any of the findings is a failure.
+func Failed(findings []Finding) bool {
+	for _, f := range findings {
+		if f.Severity == "fail" {
+			return true
+		}
+	}
+	return false
+}
+
+// Checks the environment skel runs in for common problems, generating into the
+// output directory out.
+func CheckEnvironment(out string) []Finding {
+	d := &doctor{}
+	d.checkEnvironment(out)
+	return d.findings
+}
+
+// Checks the skeleton at the given location for common problems and suspicious
+// constructs.
+func CheckSkeleton(in string) []Finding {
+	d := &doctor{}
+	d.checkSkeleton(in)
+	return d.findings
+}
+
+// Checks the directory skel uses for its own files, if it exists.
+func (d *doctor) checkOwnDir(what, dir string) {
+	if dir == "" {
+		d.warn("set $HOME, or the XDG base directory variables", "Unable to determine the %s directory", what)
+		return
+	}
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		d.ok("The %s directory '%s' does not exist yet, and will be created when needed", what, dir)
+		return
+	}
+	if err := CheckWritable(dir); err != nil {
+		d.fail(fmt.Sprintf("make '%s' a writable directory, or remove it", dir), "The %s directory is unusable: %s", what, err)
+		return
+	}
+	d.ok("The %s directory '%s' is writable", what, dir)
+}
+
+// Checks the environment skel runs in, generating into the output directory
+// out.
+func (d *doctor) checkEnvironment(out string) {
+	if err := CheckWritable(out); err != nil {
+		d.fail("choose another directory with -out, or fix its permissions", "Output directory '%s' cannot be used: %s", out, err)
+	} else {
+		d.ok("Output directory '%s' is writable", out)
+	}
+
+	locks, _ := filepath.Glob(filepath.Join(out, ".*"+LOCK_FILE))
+	for _, lock := range locks {
+		if holder, err := ioutil.ReadFile(lock); err == nil && lockHeld(lock) {
+			dir := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(lock), "."), LOCK_FILE)
+			d.warn("wait for that run to finish",
+				"Output directory '%s' is locked by another run of skel (%s)", filepath.Join(out, dir), strings.TrimSpace(string(holder)))
+		}
+	}
+
+	config := UserConfigFile()
+	if data, err := ioutil.ReadFile(config); err == nil {
+		if err := xml.Unmarshal(data, &UserConfig{}); err != nil {
+			d.fail(fmt.Sprintf("fix or remove '%s'", config), "The user configuration is invalid, and ignored: %s", err)
+		} else {
+			d.ok("The user configuration '%s' is valid", config)
+		}
+	}
+	if spec := LoadUserConfig().Owner; spec != "" {
+		if _, err := ParseOwner(spec); err != nil {
+			d.fail(fmt.Sprintf("fix the owner in '%s'", config), "Invalid owner '%s' in the user configuration: %s", spec, err)
+		}
+	}
+	for _, root := range WorkspaceRoots() {
+		if stat, err := os.Stat(root); err != nil || !stat.IsDir() {
+			d.warn(fmt.Sprintf("create it, or remove it from $%s or the user configuration", ENV_WORKSPACES), "Workspace root '%s' is not a directory", root)
+		}
+	}
+
+	d.checkOwnDir("cache", cacheDir())
+	d.checkOwnDir("state", stateDir())
+	d.checkOwnDir("registry", RegistryDir())
+
+	if _, err := exec.LookPath("git"); err != nil {
+		d.warn("install git, and make sure it is on the PATH", "git is not found, which is needed for skeletons in git repositories")
+	} else {
+		d.ok("git is available")
+	}
+}
+
+// Matches parameter names which can be used in expressions.
+var identifier = regexp.MustCompile(`^[\pL_][\pL\pN_]*$`)
+
+// Reports whether the variable name is known in the skeleton: a parameter or
+// one of its derived variants, a variable of the script, a built-in or a loop
+// variable.
+func knownVariable(t *Skeleton, name string) bool {
+	statements, _ := parseScript(t.Config.Script, t.Config.Parameters)
+	for _, s := range statements {
+		if name == s.name {
+			return true
+		}
+	}
+	if isBuiltinName(name) {
+		_, builtin := t.builtinVariables()[name]
+		return builtin || strings.HasPrefix(name, "loop.")
+	}
+	for _, p := range t.Config.Parameters {
+		if name == p.Name {
+			return true
+		}
+		if _, ok := derivedVariants(p.Name, "")[name]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Checks the placeholders in the template source, which is the path or the
+// contents of the skeleton file at path. Returns the variables used.
+func (d *doctor) checkTemplate(t *Skeleton, path, src string) []string {
+	if t.templated() {
+		return d.checkGoTemplate(t, path, src)
+	}
+	loops := 0
+	for _, tok := range lexTemplate(src) {
+		if tok.tag && isBlockTag(tok.inner) {
+			if strings.Fields(tok.inner)[0] == "loop" {
+				loops++
+			} else {
+				loops--
+			}
+		}
+	}
+	if loops != 0 {
+		d.warn("match every ${loop x} with an ${end}", "'%s' has unbalanced ${loop} and ${end} tags", path)
+	}
+	if i := strings.LastIndex(src, "${"); i >= 0 && closingBrace(src, i+2) < 0 {
+		d.warn("close the placeholder with }, it is copied as-is now", "'%s' has an unterminated placeholder", path)
+	}
+
+	var used []string
+	var walk func(nodes []interface{})
+	walk = func(nodes []interface{}) {
+		for _, n := range nodes {
+			switch node := n.(type) {
+			case exprNode:
+				if knownVariable(t, node.expr) {
+					used = append(used, node.expr)
+					continue
+				}
+				tokens, err := lexExpr(node.expr)
+				if err != nil {
+					d.warn("check the spelling, it is copied as-is now", "'%s' has an invalid placeholder %s: %s", path, node.raw, err)
+					continue
+				}
+				for i, tok := range tokens {
+					if tok.kind != tokIdent || (i+1 < len(tokens) && tokens[i+1].text == "(") {
+						continue
+					}
+					used = append(used, tok.text)
+					if !knownVariable(t, tok.text) {
+						d.warn("add it as a parameter, or check the spelling", "'%s' uses the unknown variable '%s' in %s", path, tok.text, node.raw)
+					}
+				}
+			case loopNode:
+				used = append(used, node.over)
+				if !knownVariable(t, node.over) {
+					d.warn("add it as a parameter, or check the spelling", "'%s' loops over the unknown variable '%s'", path, node.over)
+				}
+				walk(node.body)
+			}
+		}
+	}
+	walk(parseTemplate(src))
+	return used
+}
+
+// Like checkTemplate, for a skeleton using Go templates.
+func (d *doctor) checkGoTemplate(t *Skeleton, path, src string) []string {
+	used, err := goTemplateVariables(path, src)
+	if err != nil {
+		d.fail("fix the template syntax", "'%s' is not a valid template: %s", path, err)
+		return nil
+	}
+	for _, name := range used {
+		parent := strings.SplitN(name, ".", 2)[0]
+		switch {
+		case !knownVariable(t, name):
+			d.warn("add it as a parameter, or check the spelling", "'%s' uses the unknown variable '%s'", path, name)
+		case parent != name && knownVariable(t, parent):
+			d.warn("use a function like camelCase instead", "'%s' uses the derived variable '%s', which templates do not have", path, name)
+		}
+	}
+	return used
+}
+
+// Checks the skeleton at the given location for suspicious constructs.
+func (d *doctor) checkSkeleton(in string) {
+	t, err := Load(in)
+	if err != nil {
+		d.fail("give a skeleton directory or zip file containing a config.xml", "%s", err)
+		return
+	}
+	d.ok("Skeleton '%s' opens", in)
+
+	config := filepath.Join(t.Location, "config.xml")
+	data, _ := ioutil.ReadFile(config)
+	if err := xml.Unmarshal(data, &SkeletonConfig{}); err != nil {
+		d.fail("fix the XML of config.xml", "config.xml is not valid XML, so parts of it are ignored: %s", err)
+	}
+	if strings.TrimSpace(t.Config.Name) == "" {
+		d.warn("add a <name> to config.xml", "The skeleton has no name, so output directories are named '-<time>'")
+	}
+
+	seen := make(map[string]bool)
+	for _, p := range t.Config.Parameters {
+		switch {
+		case p.Name == "":
+			d.fail("give every <param> a name attribute", "A parameter has no name")
+		case seen[p.Name]:
+			d.fail("remove or rename one of them", "Parameter '%s' is declared more than once, and asked for twice", p.Name)
+		case isBuiltinName(p.Name):
+			d.fail("rename the parameter", "Parameter '%s' has the name of a built-in variable, which takes precedence", p.Name)
+		case !identifier.MatchString(p.Name):
+			d.warn("use letters, digits and underscores only", "Parameter '%s' can only be used as ${%s}, not in expressions or loops", p.Name, p.Name)
+		}
+		if p.Choices != nil && p.Choices.Command == "" {
+			if options, err := t.ChoiceOptions(*p.Choices); err != nil {
+				d.fail("point the file attribute to a file of the skeleton", "The choices of parameter '%s' cannot be read: %s", p.Name, err)
+			} else if len(options) == 0 {
+				d.fail("add choices, or remove the <choices>", "Parameter '%s' has no choices", p.Name)
+			}
+		}
+		if strings.TrimSpace(p.Description) == "" {
+			d.warn("add a description attribute", "Parameter '%s' has no description, so its prompt is empty", p.Name)
+		}
+		// defaults using other parameters can only be checked when generating
+		if p.Default != "" && !strings.Contains(p.Default, "${") && !strings.Contains(p.Default, "{{") {
+			if _, err := p.Check(p.Default); err != nil {
+				d.fail("fix the default attribute", "The default of parameter '%s' is invalid: %s", p.Name, err)
+			}
+		}
+		seen[p.Name] = true
+	}
+
+	// parameters may be used in the configuration itself as well
+	var used []string
+	for _, src := range []string{t.Config.OutDirName, t.Config.Gitignore} {
+		used = append(used, d.checkTemplate(t, config, src)...)
+	}
+	for _, p := range t.Config.Parameters {
+		used = append(used, d.checkTemplate(t, config, p.Default)...)
+	}
+	statements, _ := parseScript(t.Config.Script, t.Config.Parameters)
+	for _, s := range statements {
+		used = append(used, d.checkTemplate(t, config, "${"+s.expr+"}")...)
+	}
+	for _, h := range append(append([]SkeletonHook{}, t.Config.Hooks.Pre...), t.Config.Hooks.Post...) {
+		if !h.Script {
+			used = append(used, d.checkTemplate(t, config, h.Text)...)
+			continue
+		}
+		// variables assigned by the script itself are not known otherwise
+		statements, _ := parseHookScript(h.Text, t.Config.Parameters)
+		for _, s := range statements {
+			used = append(used, templateVariables("${"+s.expr+"}")...)
+		}
+	}
+	for _, r := range t.pathRules() {
+		if r.If != "" {
+			used = append(used, d.checkTemplate(t, config, "${"+r.If+"}")...)
+		}
+	}
+	for _, o := range t.Config.Outputs {
+		used = append(used, d.checkTemplate(t, config, o.Root)...)
+		if stat, err := os.Stat(filepath.Join(t.Location, filepath.FromSlash(o.Path))); err != nil || !stat.IsDir() {
+			d.fail("point the path attribute to a directory of the skeleton", "Output path '%s' is not a directory of the skeleton", o.Path)
+		}
+	}
+
+	if l := t.Config.Locales; l != nil {
+		used = append(used, l.Param)
+		if !knownVariable(t, l.Param) {
+			d.fail("set the param attribute to a parameter", "<locales> selects the locale with the unknown variable '%s'", l.Param)
+		}
+		variants := make(map[string]bool)
+		for _, locale := range listItems(l.List) {
+			variants[canonicalLocale(locale)] = true
+		}
+		if _, ok := matchLocale(l.Fallback, variants); l.Fallback != "" && !ok {
+			d.warn("list the fallback in <locales>", "The fallback locale '%s' is not one of the locales", l.Fallback)
+		}
+	}
+
+	matched := make(map[string]bool)
+	filepath.Walk(t.Location, func(path string, info os.FileInfo, err error) error {
+		if err != nil || path == t.Location {
+			return nil
+		}
+		rel := strings.TrimPrefix(path, filepath.Clean(t.Location))
+		used = append(used, d.checkTemplate(t, path, rel)...)
+		for _, r := range t.Config.Substitution {
+			if matchGlob(r.Glob, filepath.ToSlash(rel)) {
+				matched[r.Glob] = true
+			}
+		}
+		for _, r := range t.pathRules() {
+			if matchGlob(r.Glob, filepath.ToSlash(rel)) {
+				matched[r.Glob] = true
+			}
+		}
+		if info.IsDir() || path == config {
+			return nil
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			return nil
+		}
+		if raw, err := t.raw(rel, path); err != nil || raw {
+			return nil
+		}
+		if data, err := ioutil.ReadFile(path); err == nil {
+			used = append(used, d.checkTemplate(t, path, string(data))...)
+		}
+		return nil
+	})
+
+	for _, p := range t.Config.Parameters {
+		if p.Name != "" && !usesVariable(used, p.Name) {
+			usage := "${" + p.Name + "}"
+			if t.templated() {
+				usage = "{{." + p.Name + "}}"
+			}
+			d.warn("use it as "+usage+", or remove it", "Parameter '%s' is asked for but never used", p.Name)
+		}
+	}
+	for _, r := range t.Config.Substitution {
+		if !matched[r.Glob] {
+			d.warn("check the glob, it has no effect now", "Substitution glob '%s' matches no path of the skeleton", r.Glob)
+		}
+	}
+	for _, r := range t.pathRules() {
+		if !matched[r.Glob] {
+			d.warn("check the glob, it has no effect now", "Rule glob '%s' matches no path of the skeleton", r.Glob)
+		}
+	}
+	if g := t.Config.Gitignore; g != "" && !strings.Contains(g, "${") {
+		if _, err := assembleGitignore(listItems(g)); err != nil {
+			d.fail("list bundled templates only, separated by commas", "Invalid <gitignore>: %s", err)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package skel
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+// Encodes s as a JSON string literal, including the surrounding quotes.
+func jsonString(s string) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// Encodes a comma separated list as a JSON array of strings.
+func jsonList(s string) string {
+	items := listItems(s)
+	encoded := make([]string, len(items))
+	for i, item := range items {
+		encoded[i] = jsonString(item)
+	}
+	return "[" + strings.Join(encoded, ", ") + "]"
+}
+
+// Matches strings which can be used as a plain (unquoted) YAML scalar.
+var yamlPlain = regexp.MustCompile(`^[A-Za-z_/.][A-Za-z0-9_ ./-]*$`)
+
+// Matches plain scalars starting with a dot which YAML reads as a float, like
+// .5, .inf and .nan.
+var yamlDotNumber = regexp.MustCompile(`(?i)^\.([0-9]|(inf|nan)$)`)
+
+// Encodes s as a YAML scalar. Strings which would be misinterpreted when left
+// unquoted, like "yes", "null", numbers or anything containing special
+// characters, are double quoted.
+func yamlScalar(s string) string {
+	switch strings.ToLower(s) {
+	case "true", "false", "yes", "no", "on", "off", "y", "n", "null", "~":
+		return jsonString(s)
+	}
+	if !yamlPlain.MatchString(s) || yamlDotNumber.MatchString(s) || strings.HasSuffix(s, " ") {
+		// a JSON string is a valid double quoted YAML string
+		return jsonString(s)
+	}
+	return s
+}
+
+// Encodes a comma separated list as a YAML flow sequence.
+func yamlList(s string) string {
+	items := listItems(s)
+	encoded := make([]string, len(items))
+	for i, item := range items {
+		encoded[i] = yamlScalar(item)
+	}
+	return "[" + strings.Join(encoded, ", ") + "]"
+}
+
+// Matches strings which need no quoting in a POSIX shell.
+var shellSafe = regexp.MustCompile(`^[A-Za-z0-9@%+=:,./_-]+$`)
+
+// Quotes s for use as a single word in a POSIX shell script. Strings which
+// contain only safe characters are left as-is.
+func shellQuote(s string) string {
+	if shellSafe.MatchString(s) {
+		return s
+	}
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
+
+// Quotes s for use as a single argument of a cmd command line. Strings which
+// contain only safe characters are left as-is. A % is escaped outside of the
+// quotes, as cmd expands variables within them as well.
+func cmdQuote(s string) string {
+	if shellSafe.MatchString(s) && !strings.Contains(s, "%") {
+		return s
+	}
+	s = strings.Replace(s, `"`, `""`, -1)
+	return `"` + strings.Replace(s, "%", `"^%"`, -1) + `"`
+}
+
+// Quotes s for use as a single argument in a PowerShell script. PowerShell
+// also regards the typographic single quotes as quote characters, so these
+// are escaped (doubled) as well.
+func powershellQuote(s string) string {
+	var sb strings.Builder
+	sb.WriteRune('\'')
+	for _, r := range s {
+		switch r {
+		case '\'', '‘', '’', '‚', '‛':
+			sb.WriteRune(r)
+		}
+		sb.WriteRune(r)
+	}
+	sb.WriteRune('\'')
+	return sb.String()
+}
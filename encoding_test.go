@@ -0,0 +1,47 @@
+package skel
+
+import "testing"
+
+func TestYAMLScalar(t *testing.T) {
+	cases := map[string]string{
+		"plain":       "plain",
+		"src/main.go": "src/main.go",
+		".gitignore":  ".gitignore",
+		".info":       ".info",
+		".5":          `".5"`,
+		".5e3":        `".5e3"`,
+		".inf":        `".inf"`,
+		".Inf":        `".Inf"`,
+		".NaN":        `".NaN"`,
+		"1.5":         `"1.5"`,
+		"0x1f":        `"0x1f"`,
+		"-1":          `"-1"`,
+		"yes":         `"yes"`,
+		"Off":         `"Off"`,
+		"null":        `"null"`,
+		"~":           `"~"`,
+		"":            `""`,
+		"trailing ":   `"trailing "`,
+		"a: b":        `"a: b"`,
+	}
+	for s, want := range cases {
+		if got := yamlScalar(s); got != want {
+			t.Errorf("yamlScalar(%q) = %s, want %s", s, got, want)
+		}
+	}
+}
+
+func TestCmdQuote(t *testing.T) {
+	cases := map[string]string{
+		"plain":    "plain",
+		"a b":      `"a b"`,
+		"a & b":    `"a & b"`,
+		`say "hi"`: `"say ""hi"""`,
+		"%PATH%":   `""^%"PATH"^%""`,
+	}
+	for s, want := range cases {
+		if got := cmdQuote(s); got != want {
+			t.Errorf("cmdQuote(%q) = %s, want %s", s, got, want)
+		}
+	}
+}
@@ -0,0 +1,495 @@
+package skel
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Kinds of expression tokens.
+const (
+	tokNumber = iota
+	tokString
+	tokIdent
+	tokOperator
+	tokEOF
+)
+
+// A lexical token of an expression.
+type exprToken struct {
+	kind int
+	text string
+}
+
+// Splits an expression into tokens.
+func lexExpr(src string) ([]exprToken, error) {
+	var tokens []exprToken
+	runes := []rune(src)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case unicode.IsDigit(r):
+			start := i
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, exprToken{tokNumber, string(runes[start:i])})
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_' || runes[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, exprToken{tokIdent, string(runes[start:i])})
+		case r == '"':
+			str, n, err := lexString(runes[i:])
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, exprToken{tokString, str})
+			i += n
+		case i+1 < len(runes) && (string(runes[i:i+2]) == "==" || string(runes[i:i+2]) == "!="):
+			tokens = append(tokens, exprToken{tokOperator, string(runes[i : i+2])})
+			i += 2
+		case strings.ContainsRune("+-*/%()?:!,|", r):
+			tokens = append(tokens, exprToken{tokOperator, string(r)})
+			i++
+		default:
+			return nil, fmt.Errorf("unexpected character '%c'", r)
+		}
+	}
+	return append(tokens, exprToken{kind: tokEOF}), nil
+}
+
+// Lexes a double quoted string at the start of runes. Returns the unquoted
+// string and the number of runes consumed.
+func lexString(runes []rune) (string, int, error) {
+	var sb strings.Builder
+	for i := 1; i < len(runes); i++ {
+		switch runes[i] {
+		case '"':
+			return sb.String(), i + 1, nil
+		case '\\':
+			i++
+			if i == len(runes) {
+				break
+			}
+			switch runes[i] {
+			case 'n':
+				sb.WriteRune('\n')
+			case 't':
+				sb.WriteRune('\t')
+			default:
+				sb.WriteRune(runes[i])
+			}
+		default:
+			sb.WriteRune(runes[i])
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated string")
+}
+
+// Node types of a parsed expression.
+type (
+	// A number literal.
+	numberExpr float64
+
+	// A string literal.
+	stringExpr string
+
+	// A reference to a variable.
+	identExpr string
+
+	// A function call, like upper(x).
+	callExpr struct {
+		name string
+		args []interface{}
+	}
+
+	// An inline conditional, like x ? "yes" : "no".
+	conditionalExpr struct {
+		cond, then, otherwise interface{}
+	}
+
+	// A unary operation, like -x.
+	unaryExpr struct {
+		op      string
+		operand interface{}
+	}
+
+	// A binary operation, like x + 1.
+	binaryExpr struct {
+		op          string
+		left, right interface{}
+	}
+)
+
+// Recursive descent parser for expressions.
+type exprParser struct {
+	tokens []exprToken
+	pos    int
+}
+
+// Returns the current token.
+func (p *exprParser) peek() exprToken {
+	return p.tokens[p.pos]
+}
+
+// Consumes the current token if it is the given operator.
+func (p *exprParser) accept(op string) bool {
+	tok := p.peek()
+	if tok.kind == tokOperator && tok.text == op {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// pipeline := expression ("|" identifier ("(" arguments ")")?)*
+//
+// A filter is a function call with the value on its left as first argument,
+// so that x|padLeft(5) equals padLeft(x, 5).
+func (p *exprParser) parsePipeline() (interface{}, error) {
+	value, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("|") {
+		tok := p.peek()
+		if tok.kind != tokIdent {
+			return nil, fmt.Errorf("missing filter name after '|'")
+		}
+		p.pos++
+		filter := callExpr{name: tok.text}
+		if p.accept("(") {
+			call, err := p.parseCall(tok.text)
+			if err != nil {
+				return nil, err
+			}
+			filter = call.(callExpr)
+		}
+		filter.args = append([]interface{}{value}, filter.args...)
+		value = filter
+	}
+	return value, nil
+}
+
+// expression := equality ("?" expression ":" expression)?
+func (p *exprParser) parseExpr() (interface{}, error) {
+	cond, err := p.parseEquality()
+	if err != nil {
+		return nil, err
+	}
+	if !p.accept("?") {
+		return cond, nil
+	}
+	then, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if !p.accept(":") {
+		return nil, fmt.Errorf("missing ':' in conditional")
+	}
+	otherwise, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	return conditionalExpr{cond, then, otherwise}, nil
+}
+
+// equality := additive (("==" | "!=") additive)?
+func (p *exprParser) parseEquality() (interface{}, error) {
+	left, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+	op := p.peek().text
+	if !p.accept("==") && !p.accept("!=") {
+		return left, nil
+	}
+	right, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+	return binaryExpr{op, left, right}, nil
+}
+
+// additive := multiplicative (("+" | "-") multiplicative)*
+func (p *exprParser) parseAdditive() (interface{}, error) {
+	left, err := p.parseMultiplicative()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op := p.peek().text
+		if !p.accept("+") && !p.accept("-") {
+			return left, nil
+		}
+		right, err := p.parseMultiplicative()
+		if err != nil {
+			return nil, err
+		}
+		left = binaryExpr{op, left, right}
+	}
+}
+
+// multiplicative := unary (("*" | "/" | "%") unary)*
+func (p *exprParser) parseMultiplicative() (interface{}, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op := p.peek().text
+		if !p.accept("*") && !p.accept("/") && !p.accept("%") {
+			return left, nil
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = binaryExpr{op, left, right}
+	}
+}
+
+// unary := ("-" | "!") unary | primary
+func (p *exprParser) parseUnary() (interface{}, error) {
+	op := p.peek().text
+	if p.accept("-") || p.accept("!") {
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return unaryExpr{op, operand}, nil
+	}
+	return p.parsePrimary()
+}
+
+// call := identifier "(" arguments ")"
+// arguments := (pipeline ("," pipeline)*)?
+func (p *exprParser) parseCall(name string) (interface{}, error) {
+	call := callExpr{name: name}
+	if p.accept(")") {
+		return call, nil
+	}
+	for {
+		arg, err := p.parsePipeline()
+		if err != nil {
+			return nil, err
+		}
+		call.args = append(call.args, arg)
+		if p.accept(")") {
+			return call, nil
+		}
+		if !p.accept(",") {
+			return nil, fmt.Errorf("missing ')' in call to '%s'", name)
+		}
+	}
+}
+
+// primary := number | string | identifier | call | "(" pipeline ")"
+func (p *exprParser) parsePrimary() (interface{}, error) {
+	tok := p.peek()
+	switch {
+	case tok.kind == tokNumber:
+		p.pos++
+		f, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number '%s'", tok.text)
+		}
+		return numberExpr(f), nil
+	case tok.kind == tokString:
+		p.pos++
+		return stringExpr(tok.text), nil
+	case tok.kind == tokIdent:
+		p.pos++
+		if p.accept("(") {
+			return p.parseCall(tok.text)
+		}
+		return identExpr(tok.text), nil
+	case p.accept("("):
+		inner, err := p.parsePipeline()
+		if err != nil {
+			return nil, err
+		}
+		if !p.accept(")") {
+			return nil, fmt.Errorf("missing ')'")
+		}
+		return inner, nil
+	case tok.kind == tokEOF:
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+	return nil, fmt.Errorf("unexpected '%s'", tok.text)
+}
+
+// Parses the expression in src.
+func parseExpr(src string) (interface{}, error) {
+	tokens, err := lexExpr(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &exprParser{tokens: tokens}
+	expr, err := p.parsePipeline()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek().kind != tokEOF {
+		return nil, fmt.Errorf("unexpected '%s'", p.peek().text)
+	}
+	return expr, nil
+}
+
+// Formats a number without a fractional part when it is integral, so that
+// ${port + 1} results in "8081" rather than "8081.000000".
+func formatNumber(f float64) string {
+	if f == math.Trunc(f) && math.Abs(f) < 1e15 {
+		return strconv.FormatInt(int64(f), 10)
+	}
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// Converts a value to a number for use in arithmetic.
+func toNumber(value string) (float64, error) {
+	f, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil {
+		return 0, fmt.Errorf("'%s' is not a number", value)
+	}
+	return f, nil
+}
+
+// Reports whether a value is regarded as true in conditionals. Empty values,
+// zero and answers like "false", "no" or "off" are false, anything else is
+// true.
+func isTruthy(value string) bool {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "", "0", "false", "f", "no", "n", "off":
+		return false
+	}
+	return true
+}
+
+// Formats a boolean as "true" or "false".
+func formatBool(b bool) string {
+	return strconv.FormatBool(b)
+}
+
+// Compares two values for equality. Numbers are compared by value, so that
+// "1.0" equals "1", anything else is compared as a string.
+func valuesEqual(left, right string) bool {
+	l, lerr := toNumber(left)
+	r, rerr := toNumber(right)
+	if lerr == nil && rerr == nil {
+		return l == r
+	}
+	return left == right
+}
+
+// Evaluates a parsed expression using the variables in scope, and the given
+// functions besides those of exprFuncs, if any, like those of hook scripts.
+func evalExpr(expr interface{}, scope map[string]string, funcs map[string]exprFunc) (string, error) {
+	switch e := expr.(type) {
+	case numberExpr:
+		return formatNumber(float64(e)), nil
+	case stringExpr:
+		return string(e), nil
+	case identExpr:
+		value, ok := scope[string(e)]
+		if !ok {
+			return "", fmt.Errorf("unknown variable '%s'", string(e))
+		}
+		return value, nil
+	case callExpr:
+		args := make([]string, len(e.args))
+		for i, arg := range e.args {
+			value, err := evalExpr(arg, scope, funcs)
+			if err != nil {
+				return "", err
+			}
+			args[i] = value
+		}
+		return callFunc(e.name, args, funcs)
+	case conditionalExpr:
+		cond, err := evalExpr(e.cond, scope, funcs)
+		if err != nil {
+			return "", err
+		}
+		// only the chosen branch is evaluated
+		if isTruthy(cond) {
+			return evalExpr(e.then, scope, funcs)
+		}
+		return evalExpr(e.otherwise, scope, funcs)
+	case unaryExpr:
+		if e.op == "!" {
+			operand, err := evalExpr(e.operand, scope, funcs)
+			if err != nil {
+				return "", err
+			}
+			return formatBool(!isTruthy(operand)), nil
+		}
+		operand, err := evalNumber(e.operand, scope, funcs)
+		if err != nil {
+			return "", err
+		}
+		return formatNumber(-operand), nil
+	case binaryExpr:
+		if e.op == "==" || e.op == "!=" {
+			left, err := evalExpr(e.left, scope, funcs)
+			if err != nil {
+				return "", err
+			}
+			right, err := evalExpr(e.right, scope, funcs)
+			if err != nil {
+				return "", err
+			}
+			return formatBool(valuesEqual(left, right) == (e.op == "==")), nil
+		}
+		left, err := evalNumber(e.left, scope, funcs)
+		if err != nil {
+			return "", err
+		}
+		right, err := evalNumber(e.right, scope, funcs)
+		if err != nil {
+			return "", err
+		}
+		switch e.op {
+		case "+":
+			return formatNumber(left + right), nil
+		case "-":
+			return formatNumber(left - right), nil
+		case "*":
+			return formatNumber(left * right), nil
+		case "/":
+			if right == 0 {
+				return "", fmt.Errorf("division by zero")
+			}
+			return formatNumber(left / right), nil
+		case "%":
+			if right == 0 {
+				return "", fmt.Errorf("division by zero")
+			}
+			return formatNumber(math.Mod(left, right)), nil
+		}
+	}
+	return "", fmt.Errorf("cannot evaluate %v", expr)
+}
+
+// Evaluates a parsed expression, which must result in a number.
+func evalNumber(expr interface{}, scope map[string]string, funcs map[string]exprFunc) (float64, error) {
+	value, err := evalExpr(expr, scope, funcs)
+	if err != nil {
+		return 0, err
+	}
+	return toNumber(value)
+}
+
+// Evaluates the expression in src using the variables in scope.
+func Evaluate(src string, scope map[string]string) (string, error) {
+	expr, err := parseExpr(src)
+	if err != nil {
+		return "", err
+	}
+	return evalExpr(expr, scope, nil)
+}
@@ -0,0 +1,244 @@
+package skel
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// The outcome of extracting a skeleton from a project.
+type ExtractResult struct {
+	Parameters []string // parameters found in the project, in the order of the skeleton
+	Unused     []string // parameters of which the value does not occur in the project
+	Raw        []string // files of the project containing ${, which are declared raw
+	Warnings   []string // files containing ${ as well as values, which cannot be raw
+	Files      int      // number of files of the skeleton, besides config.xml
+}
+
+// Returns the replacements of extracting: every value, and its derived
+// variants which differ from it, by the placeholder replacing it. Values are
+// replaced in the order of their length, so a value is never replaced in part
+// by a shorter one.
+func extractReplacements(names []string, values map[string]string) []string {
+	placeholders := make(map[string]string)
+	var texts []string
+	add := func(text, placeholder string) {
+		if _, ok := placeholders[text]; !ok && text != "" {
+			placeholders[text] = placeholder
+			texts = append(texts, text)
+		}
+	}
+	for _, name := range names {
+		add(values[name], "${"+name+"}")
+	}
+	for _, name := range names {
+		variants := derivedVariants(name, values[name])
+		var keys []string
+		for k := range variants {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			add(variants[k], "${"+k+"}")
+		}
+	}
+
+	sort.SliceStable(texts, func(i, j int) bool { return len(texts[i]) > len(texts[j]) })
+	var pairs []string
+	for _, text := range texts {
+		pairs = append(pairs, text, placeholders[text])
+	}
+	return pairs
+}
+
+// Extracts a skeleton from the project directory: every occurrence of the
+// values in paths and file contents is replaced by a placeholder of its
+// parameter, like ${name}, or of the derived variant it equals, like
+// ${name.slug}. Parameters are declared in a starter config.xml, with their
+// value as default. Binary files are copied as-is. The skeleton is written to
+// out, which must not exist yet, or to an archive when out ends in .zip,
+// .tar.gz or .tgz.
+func Extract(project, out string, values map[string]string) (*ExtractResult, error) {
+	var names []string
+	for name, value := range values {
+		if !identifier.MatchString(name) {
+			return nil, fmt.Errorf("invalid parameter name '%s'", name)
+		}
+		if value == "" {
+			return nil, fmt.Errorf("parameter '%s' has no value", name)
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	if abs, err := filepath.Abs(project); err == nil {
+		project = abs
+	}
+	if stat, err := os.Stat(project); err != nil {
+		return nil, err
+	} else if !stat.IsDir() {
+		return nil, fmt.Errorf("'%s' is not a directory", project)
+	}
+	if _, err := os.Stat(filepath.Join(project, "config.xml")); err == nil {
+		return nil, fmt.Errorf("'%s' has a config.xml of its own", project)
+	}
+	if abs, err := filepath.Abs(out); err == nil && withinDir(project, abs) {
+		return nil, fmt.Errorf("the skeleton cannot be written within the project")
+	}
+	if _, err := os.Lstat(out); err == nil {
+		return nil, fmt.Errorf("'%s' exists already", out)
+	}
+
+	var sink Sink
+	switch kind := archiveKind(out); kind {
+	case "zip", "tar.gz":
+		s, err := NewArchiveSink(kind, out)
+		if err != nil {
+			return nil, err
+		}
+		sink = s
+	case "":
+		if err := os.MkdirAll(out, 0755); err != nil {
+			return nil, err
+		}
+		sink = dirSink{out}
+	default:
+		return nil, fmt.Errorf("skeletons can only be written to a directory, a zip or a tar.gz file")
+	}
+
+	result, err := extractTree(project, sink, names, values)
+	if err != nil {
+		abandon(sink)
+		// it did not exist before
+		os.RemoveAll(out)
+		return nil, err
+	}
+	if err := sink.Close(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Writes the skeleton extracted from the project to the sink.
+func extractTree(project string, sink Sink, names []string, values map[string]string) (*ExtractResult, error) {
+	replacer := strings.NewReplacer(extractReplacements(names, values)...)
+	result := &ExtractResult{}
+	var extracted []string
+
+	err := filepath.Walk(project, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(project, path)
+		if err != nil || rel == "." {
+			return err
+		}
+		if info.IsDir() && info.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		if isSkelFile(rel) {
+			return nil
+		}
+
+		target := replacer.Replace(filepath.ToSlash(rel))
+		extracted = append(extracted, target)
+		e := Entry{Path: filepath.FromSlash(target), Source: path, Dir: info.IsDir(), Mode: info.Mode()}
+		switch {
+		case e.Dir:
+			return sink.MkdirAll(e.Path, e.perm())
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			result.Files++
+			return sink.Symlink(e.Path, link)
+		}
+
+		result.Files++
+		binary, err := isBinaryFile(path)
+		if err != nil {
+			return err
+		}
+		if binary {
+			e.Raw = true
+			return writeExtracted(sink, e)
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		content := replacer.Replace(string(data))
+		extracted = append(extracted, content)
+		if bytes.Contains(data, []byte("${")) {
+			// placeholders of the project itself must not be substituted
+			if content == string(data) {
+				result.Raw = append(result.Raw, target)
+			} else {
+				result.Warnings = append(result.Warnings, fmt.Sprintf("'%s' contains ${ as well as values, which are substituted alike", rel))
+			}
+		}
+		e.Content = []byte(content)
+		return writeExtracted(sink, e)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	all := strings.Join(extracted, "\n")
+	for _, name := range names {
+		if strings.Contains(all, "${"+name+"}") || strings.Contains(all, "${"+name+".") {
+			result.Parameters = append(result.Parameters, name)
+		} else {
+			result.Unused = append(result.Unused, name)
+		}
+	}
+
+	config := extractedConfig(filepath.Base(project), result.Parameters, values, result.Raw)
+	if err := sink.WriteFile("config.xml", bytes.NewReader(config), 0644); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Writes an extracted file to the sink.
+func writeExtracted(sink Sink, e Entry) error {
+	r, err := e.Open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	return sink.WriteFile(e.Path, r, e.perm())
+}
+
+// Escapes the string for use in XML.
+func xmlEscape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// Returns the starter config.xml of an extracted skeleton, declaring the
+// parameters with their values as defaults, and the raw files.
+func extractedConfig(name string, params []string, values map[string]string, raw []string) []byte {
+	var b strings.Builder
+	b.WriteString("<skeleton>\n")
+	fmt.Fprintf(&b, "\t<name>%s</name>\n", xmlEscape(name))
+	b.WriteString("\t<version>1.0</version>\n")
+	fmt.Fprintf(&b, "\t<description>Extracted from %s.</description>\n", xmlEscape(name))
+	b.WriteString("\t<parameters>\n")
+	for _, p := range params {
+		fmt.Fprintf(&b, "\t\t<param name=\"%s\" description=\"%s\" default=\"%s\"/>\n", p, xmlEscape(p), xmlEscape(values[p]))
+	}
+	b.WriteString("\t</parameters>\n")
+	if len(raw) > 0 {
+		fmt.Fprintf(&b, "\t<raw>%s</raw>\n", xmlEscape(strings.Join(raw, ", ")))
+	}
+	b.WriteString("</skeleton>\n")
+	return []byte(b.String())
+}
@@ -0,0 +1,125 @@
+package skel
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Client with which skeleton archives are downloaded.
+var httpClient = &http.Client{Timeout: 5 * time.Minute}
+
+// Splits a remote skeleton location into the URL and the git branch, tag or
+// commit after a #, like https://github.com/org/skel.git#v1.2. Returns false
+// when the location is not remote: git repositories are given by a URL
+// ending in .git, a git://, ssh:// or git+ URL, or a scp-like location like
+// git@github.com:org/skel; any other http(s) URL is an archive.
+func remoteSkeleton(in string) (location, ref string, git, ok bool) {
+	location = in
+	if i := strings.LastIndex(in, "#"); i >= 0 {
+		location, ref = in[:i], in[i+1:]
+	}
+	u, err := url.Parse(location)
+	switch {
+	case scpLike.MatchString(location):
+		return location, ref, true, true
+	case err != nil:
+		return in, "", false, false
+	case strings.HasPrefix(u.Scheme, "git+"):
+		return strings.TrimPrefix(location, "git+"), ref, true, true
+	case u.Scheme == "git" || u.Scheme == "ssh":
+		return location, ref, true, true
+	case u.Scheme == "http" || u.Scheme == "https":
+		return location, ref, strings.HasSuffix(u.Path, ".git"), true
+	}
+	return in, "", false, false
+}
+
+// Reports whether the location is that of a remote skeleton.
+func isRemote(in string) bool {
+	_, _, _, ok := remoteSkeleton(in)
+	return ok
+}
+
+// Fetches the remote skeleton at in into a temporary directory: a git
+// repository is cloned at the ref after the #, if any, and an archive is
+// downloaded and extracted.
+func fetchSkeleton(in string) (string, error) {
+	location, ref, git, _ := remoteSkeleton(in)
+
+	if git {
+		dir, err := temps.Dir("skel-clone")
+		if err != nil {
+			return "", err
+		}
+		debugf("Cloning '%s'\n", in)
+		return dir, cloneGit(location, ref, dir)
+	}
+
+	if ref != "" {
+		return "", fmt.Errorf("only git repositories can be given a #ref")
+	}
+	archive, err := downloadArchive(location)
+	if err != nil {
+		return "", err
+	}
+	dir, err := unpackArchive(archive)
+	if err != nil {
+		return "", err
+	}
+	return skeletonRoot(dir), nil
+}
+
+// Downloads the archive at the URL into a temporary directory, and returns the
+// path of the downloaded file.
+func downloadArchive(location string) (string, error) {
+	dir, err := temps.Dir("skel-download")
+	if err != nil {
+		return "", err
+	}
+	debugf("Downloading '%s'\n", location)
+
+	resp, err := httpClient.Get(location)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("GET %s: %s", location, resp.Status)
+	}
+
+	name := "skeleton.zip"
+	if u, err := url.Parse(location); err == nil && path.Base(u.Path) != "/" && path.Base(u.Path) != "." {
+		name = path.Base(u.Path)
+	}
+	file, err := os.Create(filepath.Join(dir, name))
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	if _, err := io.Copy(file, resp.Body); err != nil {
+		return "", err
+	}
+	return file.Name(), file.Close()
+}
+
+// Returns the directory holding the config.xml of an extracted archive.
+// Archives like those of GitHub wrap everything in a single directory, which
+// is then the skeleton.
+func skeletonRoot(dir string) string {
+	if _, err := os.Stat(filepath.Join(dir, "config.xml")); err == nil {
+		return dir
+	}
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil || len(infos) != 1 || !infos[0].IsDir() {
+		return dir
+	}
+	return filepath.Join(dir, infos[0].Name())
+}
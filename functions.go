@@ -0,0 +1,153 @@
+package skel
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// A function callable from expressions, like ${upper(name)}.
+type exprFunc struct {
+	minArgs, maxArgs int
+	call             func(args []string) (string, error)
+}
+
+// All functions available in expressions, by name.
+var exprFuncs = map[string]exprFunc{
+	"trim": {1, 2, func(args []string) (string, error) {
+		if len(args) == 2 {
+			return strings.Trim(args[0], args[1]), nil
+		}
+		return strings.TrimSpace(args[0]), nil
+	}},
+	"replace": {3, 3, func(args []string) (string, error) {
+		return strings.Replace(args[0], args[1], args[2], -1), nil
+	}},
+	"concat": {1, math.MaxInt32, func(args []string) (string, error) {
+		return strings.Join(args, ""), nil
+	}},
+	"upper": {1, 1, func(args []string) (string, error) {
+		return strings.ToUpper(args[0]), nil
+	}},
+	"lower": {1, 1, func(args []string) (string, error) {
+		return strings.ToLower(args[0]), nil
+	}},
+	"padLeft": {2, 3, func(args []string) (string, error) {
+		padding, err := padding(args)
+		return padding + args[0], err
+	}},
+	"padRight": {2, 3, func(args []string) (string, error) {
+		padding, err := padding(args)
+		return args[0] + padding, err
+	}},
+	"truncate": {2, 2, func(args []string) (string, error) {
+		n, err := intArg(args[1])
+		if err != nil {
+			return "", err
+		}
+		return substring(args[0], 0, n), nil
+	}},
+	"substring": {2, 3, func(args []string) (string, error) {
+		start, err := intArg(args[1])
+		if err != nil {
+			return "", err
+		}
+		end := utf8.RuneCountInString(args[0])
+		if len(args) == 3 {
+			if end, err = intArg(args[2]); err != nil {
+				return "", err
+			}
+		}
+		return substring(args[0], start, end), nil
+	}},
+	"slug": {1, 1, func(args []string) (string, error) {
+		return slugCase(args[0]), nil
+	}},
+	"camel": {1, 1, func(args []string) (string, error) {
+		return camelCase(args[0]), nil
+	}},
+	"pascal": {1, 1, func(args []string) (string, error) {
+		return pascalCase(args[0]), nil
+	}},
+	"snake": {1, 1, func(args []string) (string, error) {
+		return snakeCase(args[0]), nil
+	}},
+	"jsonstring": {1, 1, func(args []string) (string, error) {
+		return jsonString(args[0]), nil
+	}},
+	"jsonlist": {1, 1, func(args []string) (string, error) {
+		return jsonList(args[0]), nil
+	}},
+	"yaml": {1, 1, func(args []string) (string, error) {
+		return yamlScalar(args[0]), nil
+	}},
+	"yamllist": {1, 1, func(args []string) (string, error) {
+		return yamlList(args[0]), nil
+	}},
+	"shquote": {1, 1, func(args []string) (string, error) {
+		return shellQuote(args[0]), nil
+	}},
+	"psquote": {1, 1, func(args []string) (string, error) {
+		return powershellQuote(args[0]), nil
+	}},
+}
+
+// Converts a function argument to an integer.
+func intArg(arg string) (int, error) {
+	n, err := strconv.Atoi(strings.TrimSpace(arg))
+	if err != nil {
+		return 0, fmt.Errorf("'%s' is not an integer", arg)
+	}
+	return n, nil
+}
+
+// Returns the padding needed to pad args[0] to the width in args[1], using
+// the optional padding string in args[2] (a space by default).
+func padding(args []string) (string, error) {
+	width, err := intArg(args[1])
+	if err != nil {
+		return "", err
+	}
+	pad := " "
+	if len(args) == 3 {
+		pad = args[2]
+	}
+	missing := width - utf8.RuneCountInString(args[0])
+	if missing <= 0 || pad == "" {
+		return "", nil
+	}
+	return substring(strings.Repeat(pad, missing), 0, missing), nil
+}
+
+// Returns the characters from start up to end of s, clamped to the length of
+// s. Indexes are in characters, not bytes.
+func substring(s string, start, end int) string {
+	runes := []rune(s)
+	if start < 0 {
+		start = 0
+	}
+	if end > len(runes) {
+		end = len(runes)
+	}
+	if start >= end {
+		return ""
+	}
+	return string(runes[start:end])
+}
+
+// Calls the function with the given name.
+func callFunc(name string, args []string, funcs map[string]exprFunc) (string, error) {
+	f, ok := funcs[name]
+	if !ok {
+		f, ok = exprFuncs[name]
+	}
+	if !ok {
+		return "", fmt.Errorf("unknown function '%s'", name)
+	}
+	if len(args) < f.minArgs || len(args) > f.maxArgs {
+		return "", fmt.Errorf("wrong number of arguments for '%s'", name)
+	}
+	return f.call(args)
+}
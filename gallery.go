@@ -0,0 +1,72 @@
+package skel
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Prefix of a skeleton input denoting a skeleton of the bundled gallery, like
+// "gallery:go-cli".
+const GALLERY_PREFIX = "gallery:"
+
+// Suffix of bundled skeleton files which is removed when extracting. Files
+// like go.mod and *.go cannot be embedded or are picked up by the go tool
+// under their own name.
+const GALLERY_SUFFIX = ".gallery"
+
+// Bundled starter skeletons, one directory per skeleton.
+//
+//go:embed all:_gallery
+var gallery embed.FS
+
+// Returns the names of all bundled skeletons.
+func GallerySkeletons() []string {
+	dirs, _ := gallery.ReadDir("_gallery")
+	var names []string
+	for _, d := range dirs {
+		if d.IsDir() {
+			names = append(names, d.Name())
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Extracts the bundled skeleton with the given name to a temporary directory,
+// which is removed when skel exits. Returns the directory.
+func ExtractGallery(name string) (string, error) {
+	root := path.Join("_gallery", name)
+	if !contains(GallerySkeletons(), name) {
+		return "", fmt.Errorf("no bundled skeleton '%s' (available: %s)", name, strings.Join(GallerySkeletons(), ", "))
+	}
+
+	targetDir, err := temps.Dir("skel-gallery")
+	if err != nil {
+		return "", err
+	}
+
+	err = fs.WalkDir(gallery, root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel := strings.TrimSuffix(strings.TrimPrefix(p, root), GALLERY_SUFFIX)
+		target := filepath.Join(targetDir, filepath.FromSlash(rel))
+		if d.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+		data, err := gallery.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		return ioutil.WriteFile(target, data, 0644)
+	})
+
+	return targetDir, err
+}
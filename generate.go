@@ -0,0 +1,423 @@
+package skel
+
+import (
+	"crypto/ed25519"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Options of generating output from a skeleton.
+type Options struct {
+	Values     map[string]string  // values of the parameters
+	Outdir     string             // directory, archive (.zip, .tar.gz) or sftp:// URL in which the output is generated
+	Name       string             // name of the output directory, which may contain ${x} (default from the skeleton, or name-timestamp)
+	NoSuffix   bool               // whether to name the output directory after the skeleton only, without the time
+	Target     string             // directory the output is generated in directly, instead of a new one within Outdir
+	DryRun     bool               // whether to render only, without writing anything
+	Owner      *Owner             // owner of the generated files and directories, if set
+	Conflict   string             // what to do with files which exist already: CONFLICT_FAIL (the default) or CONFLICT_PROMPT
+	Resolve    ResolveFunc        // asks what to do with a file which exists already, for CONFLICT_PROMPT
+	SigningKey ed25519.PrivateKey // key with which a provenance attestation of the output is signed, if set
+	LockWait   time.Duration      // how long to wait for another run generating into the same directory
+	NoHooks    bool               // whether to leave out the hooks of the skeleton
+	KeepTimes  bool               // whether to give the output the modification times of the skeleton files
+	Random     *ManifestRandom    // random values, date and user to use, like those of an earlier generation, instead of new ones
+	Rollback   bool               // whether to remove what was created in place, and restore replaced files, when generating fails
+	Generated  []string           // slash separated paths an earlier generation into the target created, which are not recorded as existing
+}
+
+// The outcome of generating output.
+type Result struct {
+	OutputDir     string   // the directory, archive or remote directory the output was generated in
+	Entries       []Entry  // the generated directories and files, sorted by path
+	Unsubstituted []string // variables which were left unsubstituted, sorted
+	Warnings      []string // skeleton files which were skipped, and why
+	Created       []string // the paths which were created locally, in order; none for a remote host
+	Errors        []string // the errors of a failed run, one for each
+	RolledBack    bool     // whether a failed run was rolled back
+}
+
+var (
+	// Held while a single file or directory is being generated, so that an
+	// interrupt never cleans up halfway a write.
+	generationLock sync.Mutex
+)
+
+// Stops all generation after the file currently being written; generation
+// never continues. To be called on an interrupt, before Cleanup.
+func Abort() {
+	generationLock.Lock()
+}
+
+// Abandons the output written to the sink so far, after generating failed. The
+// output of a remote host is never completed, so nothing is extracted there.
+func abandon(sink Sink) {
+	if s, ok := sink.(*sshSink); ok {
+		s.cmd.Process.Kill()
+		s.cmd.Wait()
+		return
+	}
+	if s, ok := sink.(*sftpSink); ok {
+		// nothing is uploaded yet
+		temps.Remove(s.root)
+		return
+	}
+	sink.Close()
+}
+
+// Opens the skeleton at the given location and generates output from it, like
+// Load followed by Generate on the skeleton.
+func Generate(in string, opts Options) (*Result, error) {
+	t, err := Load(in)
+	if err != nil {
+		return nil, err
+	}
+	return t.Generate(opts)
+}
+
+// Generates output from the skeleton, with the given options. The output is
+// generated in a staging directory first, so it only appears when it is
+// complete. Locks on the output directories are held while generating.
+func (t *Skeleton) Generate(opts Options) (*Result, error) {
+	if opts.Conflict == "" {
+		opts.Conflict = CONFLICT_FAIL
+	}
+	if !contains(ConflictPolicies, opts.Conflict) {
+		return nil, fmt.Errorf("Invalid conflict policy '%s', expected one of: %s", opts.Conflict, strings.Join(ConflictPolicies, ", "))
+	}
+
+	t.Dryrun = opts.DryRun
+	t.Outdir = opts.Outdir
+	if opts.Random != nil {
+		t.uuid, t.randomhex = opts.Random.UUID, opts.Random.RandomHex
+		if opts.Random.Date != "" {
+			t.date = opts.Random.Date
+		}
+		if opts.Random.User != "" {
+			t.user = opts.Random.User
+		}
+	}
+	values, err := t.checkValues(opts.Values)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid parameters: %s", err)
+	}
+	t.KeyValues = values
+
+	// the output directory is named by the skeleton or the options, instead
+	// of after the skeleton and the time
+	switch {
+	case opts.Target != "":
+		if opts.Name != "" {
+			return nil, fmt.Errorf("A target directory cannot be given a name as well")
+		}
+		if _, remote, _ := remoteTarget(opts.Target); remote || archiveKind(opts.Target) != "" {
+			return nil, fmt.Errorf("Invalid target directory '%s': it must be a local directory", opts.Target)
+		}
+		target := filepath.Clean(opts.Target)
+		if abs, err := filepath.Abs(target); err == nil {
+			target = abs
+		}
+		name, err := checkDirName(filepath.Base(target))
+		if err != nil {
+			return nil, fmt.Errorf("Invalid target directory: %s", err)
+		}
+		t.Outdir, t.outDirBase = filepath.Dir(target), name
+	case opts.Name != "" || t.Config.OutDirName != "":
+		name := opts.Name
+		if name == "" {
+			name = t.Config.OutDirName
+		}
+		if err := t.setOutDirName(name); err != nil {
+			return nil, fmt.Errorf("Invalid output directory name: %s", err)
+		}
+	case opts.NoSuffix:
+		name, err := checkDirName(t.Config.Name)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to name the output directory after the skeleton: %s", err)
+		}
+		t.outDirBase = name
+	}
+
+	// output to a remote host, or to a zip or tar.gz file instead of a
+	// directory
+	remote, isRemote, err := remoteTarget(t.Outdir)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid remote output directory '%s': %s", t.Outdir, err)
+	}
+	archive := ""
+	if isRemote {
+		t.remote = &remote
+		if opts.Owner != nil {
+			return nil, fmt.Errorf("The owner of remote output cannot be set.")
+		}
+	} else {
+		archive = archiveKind(t.Outdir)
+	}
+	if archive != "" {
+		t.outDirBase = filepath.Base(t.Outdir)
+		t.Outdir = filepath.Dir(t.Outdir)
+	}
+
+	// hooks run in the output directory, which archives and remote hosts
+	// do not have
+	hooks := !opts.NoHooks && (len(t.Config.Hooks.Pre) > 0 || len(t.Config.Hooks.Post) > 0)
+	if hooks && (isRemote || archive != "") {
+		t.warnf("Not running the hooks of the skeleton, which only run for output in a local directory")
+		hooks = false
+	}
+
+	// generate in a staging directory (or file) first, so the output only
+	// appears when it is complete
+	var sink Sink = discardSink{}
+	var staged string
+	if !t.Dryrun && isRemote {
+		// written directly, the remote directory must not exist yet
+		newSink := NewSSHSink
+		if remote.Scheme == "sftp" {
+			newSink = NewSFTPSink
+		}
+		if sink, err = newSink(remote, t.outDirBase); err != nil {
+			return nil, fmt.Errorf("Unable to connect to '%s': %s", remote.Host, err)
+		}
+	} else if !t.Dryrun {
+		lock, err := LockDir(t.outputDir(), opts.LockWait)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to lock output directory: %s", err)
+		}
+		defer lock.Unlock()
+
+		staging, err := temps.Dir("skel-staging")
+		if err != nil {
+			return nil, fmt.Errorf("Unable to create staging directory: %s", err)
+		}
+		defer temps.Remove(staging)
+
+		staged = staging
+		sink = dirSink{staging}
+		if archive != "" {
+			staged = filepath.Join(staging, t.outDirBase)
+			if sink, err = NewArchiveSink(archive, staged); err != nil {
+				return nil, fmt.Errorf("Unable to create archive: %s", err)
+			}
+		}
+	}
+
+	// what is created in place is recorded, so a failed run can be rolled
+	// back
+	j := newJournal(opts.Rollback)
+
+	// pre-generate hooks run in the output directory, which is created for
+	// them; the output is then merged into it
+	createdForHooks := false
+	if hooks && len(t.Config.Hooks.Pre) > 0 {
+		dir := t.outputDir()
+		if !t.Dryrun {
+			if _, err := os.Lstat(dir); os.IsNotExist(err) {
+				createdForHooks = true
+			}
+			if err := j.mkdirAll(dir, 0755); err != nil {
+				abandon(sink)
+				return t.failed(nil, j, opts, err, fmt.Errorf("Unable to create output directory: %s", err))
+			}
+		}
+		if err := t.runHooks("pre-generate", t.Config.Hooks.Pre, dir); err != nil {
+			abandon(sink)
+			// nothing is generated yet, so what was created for the hooks
+			// is removed, rollback or not
+			j.undo()
+			return t.failed(nil, j, opts, err, fmt.Errorf("Unable to generate output: %s", err))
+		}
+	}
+
+	entries, err := t.Walk(sink)
+	result := &Result{OutputDir: t.outputDir(), Entries: entries, Warnings: t.Warnings()}
+	if err != nil {
+		abandon(sink)
+		return t.failed(result, j, opts, err, fmt.Errorf("Unable to generate output: %s", err))
+	}
+	for k := range t.Unsubstituted {
+		result.Unsubstituted = append(result.Unsubstituted, k)
+	}
+	sort.Strings(result.Unsubstituted)
+
+	// the output directory may exist already, like when it is named by a
+	// template; the output is then merged into it
+	merge := archive == "" && !isRemote && !t.Dryrun
+	if _, err := os.Stat(t.outputDir()); err != nil {
+		merge = false
+	}
+	var resolutions map[string]string
+	if merge {
+		if resolutions, err = resolveConflicts(t.outputDir(), entriesIn(entries, ""), opts.Conflict, opts.Resolve); err != nil {
+			abandon(sink)
+			return t.failed(result, j, opts, err, fmt.Errorf("Unable to generate output in '%s': %s", t.outputDir(), err))
+		}
+		// recorded in the manifest, so undo leaves them alone
+		t.existing = existingPaths(t.outputDir(), entriesIn(entries, ""))
+		if createdForHooks {
+			// the directory itself is generated
+			t.existing = t.existing[1:]
+		}
+		// like when generating again, so undo still removes them
+		if opts.Generated != nil {
+			var existing []string
+			for _, p := range t.existing {
+				if !contains(opts.Generated, p) {
+					existing = append(existing, p)
+				}
+			}
+			t.existing = existing
+		}
+	}
+
+	// parts of the skeleton mapped to other roots, which may exist already
+	roots := outputRoots(entries)
+	rootResolutions := make(map[string]map[string]string)
+	for _, root := range roots {
+		if t.Dryrun {
+			for _, e := range entriesIn(entries, root) {
+				t.writeEntry(discardSink{}, e)
+			}
+			continue
+		}
+		// the output directory is locked already
+		if abs, _ := filepath.Abs(t.outputDir()); abs != root {
+			lock, err := LockDir(root, opts.LockWait)
+			if err != nil {
+				abandon(sink)
+				return t.failed(result, j, opts, err, fmt.Errorf("Unable to lock output directory: %s", err))
+			}
+			defer lock.Unlock()
+		}
+		if rootResolutions[root], err = resolveConflicts(root, entriesIn(entries, root), opts.Conflict, opts.Resolve); err != nil {
+			abandon(sink)
+			return t.failed(result, j, opts, err, fmt.Errorf("Unable to generate output in '%s': %s", root, err))
+		}
+	}
+
+	if t.Dryrun {
+		if hooks {
+			t.runHooks("post-generate", t.Config.Hooks.Post, t.outputDir())
+		}
+		return result, nil
+	}
+
+	if err := t.WriteManifest(sink, entries); err != nil {
+		abandon(sink)
+		return t.failed(result, j, opts, err, fmt.Errorf("Unable to write '%s': %s", MANIFEST_FILE, err))
+	}
+	if err := t.WriteAnswers(sink); err != nil {
+		abandon(sink)
+		return t.failed(result, j, opts, err, fmt.Errorf("Unable to write '%s': %s", ANSWERS_FILE, err))
+	}
+	skelFiles := []Entry{{Path: MANIFEST_FILE}, {Path: ANSWERS_FILE}}
+	if opts.SigningKey != nil {
+		if err := t.WriteAttestation(sink, entries, opts.SigningKey); err != nil {
+			abandon(sink)
+			return t.failed(result, j, opts, err, fmt.Errorf("Unable to write '%s': %s", ATTESTATION_FILE, err))
+		}
+		skelFiles = append(skelFiles, Entry{Path: ATTESTATION_FILE}, Entry{Path: ATTESTATION_SIGNATURE})
+	}
+	if err := sink.Close(); err != nil {
+		return t.failed(result, j, opts, err, fmt.Errorf("Unable to write output: %s", err))
+	}
+
+	switch {
+	case isRemote:
+	case archive != "":
+		if err = j.place(t.outputDir()); err == nil {
+			err = moveFile(staged, t.outputDir())
+		}
+	case merge:
+		err = mergeTree(staged, t.outputDir(), append(entriesIn(entries, ""), skelFiles...), resolutions, j)
+	default:
+		if err = j.mkdirAll(filepath.Dir(t.outputDir()), 0755); err == nil {
+			j.moved(t.outputDir(), append(entriesIn(entries, ""), skelFiles...))
+			err = moveTree(staged, t.outputDir())
+		}
+	}
+	if err != nil {
+		return t.failed(result, j, opts, err, fmt.Errorf("Unable to move generated output to '%s': %s", t.outputDir(), err))
+	}
+	var errs Errors
+	for _, root := range roots {
+		if err := t.writeRoot(root, entriesIn(entries, root), rootResolutions[root], j); err != nil {
+			errs = append(errs, fmt.Errorf("in '%s': %s", root, err))
+		}
+	}
+	if errs != nil {
+		return t.failed(result, j, opts, errs, fmt.Errorf("Unable to generate output: %s", errs))
+	}
+
+	if opts.Owner != nil {
+		if archive != "" {
+			err = os.Lchown(t.outputDir(), opts.Owner.Uid, opts.Owner.Gid)
+		} else {
+			err = t.Chown(entries, *opts.Owner, resolutions, rootResolutions)
+		}
+		if err != nil {
+			return t.failed(result, j, opts, err, fmt.Errorf("Unable to change owner of generated output: %s", err))
+		}
+	}
+
+	if opts.KeepTimes && !isRemote && archive == "" {
+		if err := setModTimes(t.outputDir(), entriesIn(entries, ""), resolutions); err != nil {
+			return t.failed(result, j, opts, err, fmt.Errorf("Unable to set modification times of generated output: %s", err))
+		}
+		for _, root := range roots {
+			if err := setModTimes(root, entriesIn(entries, root), rootResolutions[root]); err != nil {
+				return t.failed(result, j, opts, err, fmt.Errorf("Unable to set modification times of generated output: %s", err))
+			}
+		}
+	}
+
+	// the output is complete, so a failing hook leaves it for undo, unless
+	// it is rolled back
+	if hooks {
+		before, err := projectFiles(t.outputDir())
+		if err != nil {
+			return t.failed(result, j, opts, err, fmt.Errorf("Unable to list generated output: %s", err))
+		}
+		if err := t.runHooks("post-generate", t.Config.Hooks.Post, t.outputDir()); err != nil {
+			return t.failed(result, j, opts, err, fmt.Errorf("Generated output in '%s', but %s", t.outputDir(), err))
+		}
+		if err := t.recordHooked(before, entries, opts.SigningKey); err != nil {
+			return t.failed(result, j, opts, err, fmt.Errorf("Unable to record the files created by hooks: %s", err))
+		}
+	}
+
+	result.Created = j.created
+	return result, nil
+}
+
+// Ends a failed run with the given error: what was created in place is rolled
+// back, when asked to, and the result reports the errors of cause, one for
+// each, and what is left.
+func (t Skeleton) failed(result *Result, j *journal, opts Options, cause, err error) (*Result, error) {
+	if result == nil {
+		result = &Result{OutputDir: t.outputDir(), Warnings: t.Warnings()}
+	}
+	result.Errors = errorMessages(cause)
+	switch {
+	case !opts.Rollback:
+	case t.remote != nil:
+		logf("Output on a remote host cannot be rolled back\n")
+	default:
+		if len(j.created) > 0 || len(j.replaced) > 0 {
+			logf("Rolling back the output of the failed run\n")
+		}
+		if uerr := j.undo(); uerr != nil {
+			err = fmt.Errorf("%s\nUnable to roll back: %s", err, uerr)
+			result.Errors = append(result.Errors, errorMessages(uerr)...)
+		} else {
+			result.RolledBack = true
+		}
+	}
+	result.Created = j.created
+	return result, err
+}
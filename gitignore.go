@@ -0,0 +1,100 @@
+package skel
+
+import (
+	"embed"
+	"fmt"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Bundled .gitignore templates, one file per language or tool.
+//
+//go:embed gitignore/*.gitignore
+var gitignoreTemplates embed.FS
+
+// Returns the names of all bundled .gitignore templates.
+func GitignoreTemplates() []string {
+	files, _ := gitignoreTemplates.ReadDir("gitignore")
+	var names []string
+	for _, f := range files {
+		names = append(names, strings.TrimSuffix(f.Name(), ".gitignore"))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Assembles a .gitignore from the bundled templates with the given names,
+// which are matched case insensitively. Each template is preceded by a
+// header naming it.
+func assembleGitignore(names []string) ([]byte, error) {
+	var sb strings.Builder
+	for _, name := range names {
+		template := ""
+		for _, t := range GitignoreTemplates() {
+			if strings.EqualFold(t, name) {
+				template = t
+			}
+		}
+		if template == "" {
+			return nil, fmt.Errorf("no .gitignore template '%s' (available: %s)", name, strings.Join(GitignoreTemplates(), ", "))
+		}
+
+		data, err := gitignoreTemplates.ReadFile(path.Join("gitignore", template+".gitignore"))
+		if err != nil {
+			return nil, err
+		}
+		if sb.Len() > 0 {
+			sb.WriteString("\n")
+		}
+		fmt.Fprintf(&sb, "### %s ###\n", template)
+		sb.Write(data)
+	}
+	return []byte(sb.String()), nil
+}
+
+// Adds the .gitignore requested by the <gitignore> element of the skeleton
+// configuration to the entries. Its value is substituted like any other
+// content, and is a comma separated list of template names, typically taken
+// from a parameter. When the skeleton has a .gitignore of its own, the
+// templates are appended to it.
+func (t Skeleton) addGitignore(entries []Entry) ([]Entry, error) {
+	if strings.TrimSpace(t.Config.Gitignore) == "" {
+		return entries, nil
+	}
+
+	value, err := t.findReplace(t.Config.Gitignore)
+	if err != nil {
+		return nil, fmt.Errorf("invalid <gitignore>: %s", err)
+	}
+	names := listItems(value)
+	if len(names) == 0 {
+		return entries, nil
+	}
+
+	content, err := assembleGitignore(names)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, e := range entries {
+		if e.Path == ".gitignore" && !e.Dir {
+			if e.Content, err = e.Bytes(); err != nil {
+				return nil, err
+			}
+			entries[i].Raw = false
+			if len(e.Content) > 0 && !strings.HasSuffix(string(e.Content), "\n") {
+				e.Content = append(e.Content, '\n')
+			}
+			entries[i].Content = append(append(e.Content, '\n'), content...)
+			return entries, nil
+		}
+	}
+
+	entries = append(entries, Entry{Path: ".gitignore", Content: content})
+	sort.Slice(entries, func(i, j int) bool {
+		return filepath.ToSlash(entries[i].Path) < filepath.ToSlash(entries[j].Path)
+	})
+	return entries, nil
+}
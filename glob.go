@@ -0,0 +1,43 @@
+package skel
+
+import (
+	"path"
+	"strings"
+)
+
+// Reports whether the slash separated path matches the glob pattern. Besides
+// the wildcards of path.Match, a "**" element matches any number of path
+// elements, including none. A pattern without a slash matches the last
+// element of the path, like "*.png" matches "img/logo.png".
+func matchGlob(pattern, name string) bool {
+	pattern = strings.Trim(pattern, "/")
+	name = strings.Trim(name, "/")
+	if !strings.Contains(pattern, "/") && pattern != "**" {
+		ok, _ := path.Match(pattern, path.Base(name))
+		return ok
+	}
+	return matchElements(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+// Matches the path elements against the pattern elements.
+func matchElements(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			// try to let ** match none, one or more elements
+			for i := 0; i <= len(name); i++ {
+				if matchElements(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
@@ -0,0 +1,3 @@
+module github.com/krpors/skel
+
+go 1.18
@@ -0,0 +1,176 @@
+package skel
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+// Engines rendering the names and contents of a skeleton, as given by the
+// <engine> element of its configuration.
+const (
+	ENGINE_SUBSTITUTION = "substitution"  // ${x} placeholders, loops and expressions (the default)
+	ENGINE_TEMPLATE     = "text/template" // Go templates, like {{if .docker}} and {{range .modules}}
+)
+
+// Reports whether the skeleton is rendered with Go templates instead of ${x}
+// substitution.
+func (t Skeleton) templated() bool {
+	return t.Config.Engine == ENGINE_TEMPLATE
+}
+
+// Checks whether the engine is known. An empty engine denotes substitution.
+func checkEngine(engine string) error {
+	switch engine {
+	case "", ENGINE_SUBSTITUTION, ENGINE_TEMPLATE:
+		return nil
+	}
+	return fmt.Errorf("unknown engine '%s', expected %s or %s", engine, ENGINE_SUBSTITUTION, ENGINE_TEMPLATE)
+}
+
+// Functions available in Go templates: those of expressions, like lower and
+// shquote, and the usual names of the case conversions.
+var templateFuncs = template.FuncMap{
+	"camelCase":  camelCase,
+	"pascalCase": pascalCase,
+	"snakeCase":  snakeCase,
+	"kebabCase":  slugCase,
+	"join":       strings.Join,
+	"split":      listItems,
+	"truthy":     isTruthy,
+}
+
+func init() {
+	for name, f := range exprFuncs {
+		if _, ok := templateFuncs[name]; !ok {
+			templateFuncs[name] = templateFunc(name, f)
+		}
+	}
+}
+
+// Adapts a function of expressions to Go templates.
+func templateFunc(name string, f exprFunc) func(args ...string) (string, error) {
+	return func(args ...string) (string, error) {
+		if len(args) < f.minArgs || len(args) > f.maxArgs {
+			return "", fmt.Errorf("%s expects %d to %d arguments, got %d", name, f.minArgs, f.maxArgs, len(args))
+		}
+		return f.call(args)
+	}
+}
+
+// Returns the variables in scope as the data of a Go template. Variables like
+// skel.version are nested, so they are used as {{.skel.version}}; derived
+// variants like name.slug are left out, the functions are used instead. List
+// parameters are lists, to range over, and the answers "true" and "false" are
+// booleans.
+func (t Skeleton) templateData(scope map[string]string) map[string]interface{} {
+	lists := make(map[string]bool)
+	for _, p := range t.Config.Parameters {
+		lists[p.Name] = p.List
+	}
+
+	data := make(map[string]interface{})
+	for k, v := range scope {
+		var value interface{} = v
+		switch {
+		case lists[k]:
+			value = listItems(v)
+		case v == "true" || v == "false":
+			value = v == "true"
+		}
+
+		elements := strings.Split(k, ".")
+		if _, derived := scope[elements[0]]; derived && len(elements) > 1 {
+			continue
+		}
+		parent := data
+		for _, e := range elements[:len(elements)-1] {
+			child, ok := parent[e].(map[string]interface{})
+			if !ok {
+				child = make(map[string]interface{})
+				parent[e] = child
+			}
+			parent = child
+		}
+		parent[elements[len(elements)-1]] = value
+	}
+	return data
+}
+
+// Parses the Go template src, named after where it comes from.
+func parseGoTemplate(name, src string) (*template.Template, error) {
+	return template.New(name).Funcs(templateFuncs).Option("missingkey=error").Parse(src)
+}
+
+// Renders the Go template src with the variables in scope.
+func (t Skeleton) renderGoTemplate(src string, scope map[string]string) (string, error) {
+	tmpl, err := parseGoTemplate("", src)
+	if err != nil {
+		return "", err
+	}
+	var out strings.Builder
+	if err := tmpl.Execute(&out, t.templateData(scope)); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+// Returns the names of the variables referenced as fields in the Go template
+// src, like name for {{.name}} and skel.version for {{.skel.version}}. Fields
+// within range and with, where the dot is something else, are only included
+// when referenced from the root, like {{$.name}}.
+func goTemplateVariables(name, src string) ([]string, error) {
+	tmpl, err := parseGoTemplate(name, src)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	var walk func(n parse.Node, root bool)
+	walk = func(n parse.Node, root bool) {
+		switch node := n.(type) {
+		case *parse.ListNode:
+			if node != nil {
+				for _, c := range node.Nodes {
+					walk(c, root)
+				}
+			}
+		case *parse.ActionNode:
+			walk(node.Pipe, root)
+		case *parse.IfNode:
+			walk(node.Pipe, root)
+			walk(node.List, root)
+			walk(node.ElseList, root)
+		case *parse.RangeNode:
+			walk(node.Pipe, root)
+			walk(node.List, false)
+			walk(node.ElseList, root)
+		case *parse.WithNode:
+			walk(node.Pipe, root)
+			walk(node.List, false)
+			walk(node.ElseList, root)
+		case *parse.PipeNode:
+			if node != nil {
+				for _, c := range node.Cmds {
+					walk(c, root)
+				}
+			}
+		case *parse.CommandNode:
+			for _, a := range node.Args {
+				walk(a, root)
+			}
+		case *parse.FieldNode:
+			if root {
+				names = append(names, strings.Join(node.Ident, "."))
+			}
+		case *parse.VariableNode:
+			if len(node.Ident) > 1 && node.Ident[0] == "$" {
+				names = append(names, strings.Join(node.Ident[1:], "."))
+			}
+		}
+	}
+	if tmpl.Tree != nil {
+		walk(tmpl.Tree.Root, true)
+	}
+	return names, nil
+}
@@ -0,0 +1,250 @@
+package skel
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Commands run around generating, like git init or go mod init ${module}.
+// Commands are substituted and run with the system shell, and scripts by skel
+// itself, one after another; generating stops at the first one failing.
+type SkeletonHooks struct {
+	Pre  []SkeletonHook `xml:"pre-generate"`  // run in the output directory, before generating
+	Post []SkeletonHook `xml:"post-generate"` // run in the output directory, after generating
+}
+
+// A single hook: a command, or a script which skel runs itself, so it works
+// the same on every platform, without a shell.
+type SkeletonHook struct {
+	Text   string `xml:",chardata"`   // the command or the script
+	Script bool   `xml:"script,attr"` // whether the hook is a script rather than a command
+}
+
+// Returns the environment of hooks: that of skel, with the value of every
+// parameter as SKEL_<NAME>, like SKEL_MODULE for ${module}, and the output
+// directory as SKEL_OUTDIR.
+func (t Skeleton) hookEnvironment() []string {
+	env := os.Environ()
+	var names []string
+	for k := range t.KeyValues {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	for _, k := range names {
+		env = append(env, hookVariable(k)+"="+t.KeyValues[k])
+	}
+	return append(env, "SKEL_OUTDIR="+t.outputDir())
+}
+
+// Returns the name of the environment variable holding the parameter: SKEL_
+// followed by the name in upper case, with anything but letters and digits
+// replaced by underscores.
+func hookVariable(name string) string {
+	return "SKEL_" + strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// Runs the hooks in the directory dir, printing every command to Log before
+// it runs. The output of the commands goes to Log as well. Nothing is run in a
+// dry run. Substituted values are quoted, so a value is never run as a
+// command of its own.
+func (t Skeleton) runHooks(kind string, hooks []SkeletonHook, dir string) error {
+	quoted := t
+	quoted.quote = shellWord
+	for _, h := range hooks {
+		if h.Script {
+			if err := t.runHookScript(kind, h.Text, dir); err != nil {
+				return err
+			}
+			continue
+		}
+		c := h.Text
+		command, err := quoted.findReplace(strings.TrimSpace(c))
+		if err != nil {
+			return fmt.Errorf("unable to render the %s hook '%s': %s", kind, c, err)
+		}
+		if command == "" {
+			continue
+		}
+		if t.Dryrun {
+			logf("Would run %s hook: %s\n", kind, command)
+			continue
+		}
+		logf("Running %s hook: %s\n", kind, command)
+
+		cmd := shellCommand(command)
+		cmd.Dir = dir
+		cmd.Env = t.hookEnvironment()
+		stderr := new(bytes.Buffer)
+		cmd.Stdout = Log
+		cmd.Stderr = io.MultiWriter(Log, stderr)
+		if err := cmd.Run(); err != nil {
+			if msg := strings.TrimSpace(stderr.String()); msg != "" {
+				err = fmt.Errorf("%s: %s", err, msg)
+			}
+			return fmt.Errorf("the %s hook '%s' failed: %s", kind, command, err)
+		}
+	}
+	return nil
+}
+
+// Runs the hook script in the directory dir, printing every statement to Log
+// before it runs. Unlike a <script>, the first statement failing stops it.
+// Nothing is run in a dry run.
+func (t Skeleton) runHookScript(kind, src, dir string) error {
+	statements, err := parseHookScript(src, t.Config.Parameters)
+	if err != nil {
+		return fmt.Errorf("invalid %s hook script: %s", kind, err)
+	}
+	vars := t.variables()
+	funcs := t.hookFuncs(dir)
+	for _, s := range statements {
+		statement := s.expr
+		if s.name != "" {
+			statement = s.name + " = " + s.expr
+		}
+		if t.Dryrun {
+			logf("Would run %s hook: %s\n", kind, statement)
+			continue
+		}
+		logf("Running %s hook: %s\n", kind, statement)
+
+		expr, _ := parseExpr(s.expr)
+		value, err := evalExpr(expr, vars, funcs)
+		if err != nil {
+			return fmt.Errorf("the %s hook script failed at line %d: %s", kind, s.line, err)
+		}
+		if s.name != "" {
+			vars[s.name] = value
+		}
+	}
+	return nil
+}
+
+// Returns the functions of hook scripts, besides those of expressions, with
+// relative paths resolved against dir. Paths are slash separated on every
+// platform.
+func (t Skeleton) hookFuncs(dir string) map[string]exprFunc {
+	resolve := func(p string) string {
+		if p = filepath.FromSlash(p); filepath.IsAbs(p) {
+			return p
+		}
+		return filepath.Join(dir, p)
+	}
+	return map[string]exprFunc{
+		"mkdir": {1, 1, func(args []string) (string, error) {
+			return "", os.MkdirAll(resolve(args[0]), 0755)
+		}},
+		"write": {2, 2, func(args []string) (string, error) {
+			return "", ioutil.WriteFile(resolve(args[0]), []byte(args[1]), 0644)
+		}},
+		"append": {2, 2, func(args []string) (string, error) {
+			f, err := os.OpenFile(resolve(args[0]), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+			if err != nil {
+				return "", err
+			}
+			if _, err := f.WriteString(args[1]); err != nil {
+				f.Close()
+				return "", err
+			}
+			return "", f.Close()
+		}},
+		"read": {1, 1, func(args []string) (string, error) {
+			data, err := ioutil.ReadFile(resolve(args[0]))
+			return string(data), err
+		}},
+		"exists": {1, 1, func(args []string) (string, error) {
+			_, err := os.Lstat(resolve(args[0]))
+			return formatBool(err == nil), nil
+		}},
+		"copy": {2, 2, func(args []string) (string, error) {
+			info, err := os.Stat(resolve(args[0]))
+			if err != nil {
+				return "", err
+			}
+			return "", copyFile(resolve(args[0]), resolve(args[1]), info.Mode().Perm())
+		}},
+		"move": {2, 2, func(args []string) (string, error) {
+			return "", os.Rename(resolve(args[0]), resolve(args[1]))
+		}},
+		"remove": {1, 1, func(args []string) (string, error) {
+			return "", os.RemoveAll(resolve(args[0]))
+		}},
+		"chmod": {2, 2, func(args []string) (string, error) {
+			mode, err := strconv.ParseUint(args[1], 8, 32)
+			if err != nil {
+				return "", fmt.Errorf("'%s' is not an octal mode", args[1])
+			}
+			return "", os.Chmod(resolve(args[0]), os.FileMode(mode))
+		}},
+		"env": {1, 1, func(args []string) (string, error) {
+			return os.Getenv(args[0]), nil
+		}},
+		"fail": {1, 1, func(args []string) (string, error) {
+			return "", errors.New(args[0])
+		}},
+		// runs a program without a shell, returning its output
+		"run": {1, math.MaxInt32, func(args []string) (string, error) {
+			cmd := exec.Command(args[0], args[1:]...)
+			cmd.Dir = dir
+			cmd.Env = t.hookEnvironment()
+			stdout, stderr := new(bytes.Buffer), new(bytes.Buffer)
+			cmd.Stdout = io.MultiWriter(Log, stdout)
+			cmd.Stderr = io.MultiWriter(Log, stderr)
+			if err := cmd.Run(); err != nil {
+				if msg := strings.TrimSpace(stderr.String()); msg != "" {
+					err = fmt.Errorf("%s: %s", err, msg)
+				}
+				return "", fmt.Errorf("'%s' failed: %s", args[0], err)
+			}
+			return strings.TrimRight(stdout.String(), "\r\n"), nil
+		}},
+	}
+}
+
+// Records the files which the post-generate hooks created in the output
+// directory, like a go.sum, in the manifest, so verify does not report them as
+// added. Before lists the files of the output before the hooks ran. The
+// attestation covers the manifest, so it is signed again.
+func (t *Skeleton) recordHooked(before map[string]bool, entries []Entry, key ed25519.PrivateKey) error {
+	after, err := projectFiles(t.outputDir())
+	if err != nil {
+		return err
+	}
+	for rel := range after {
+		if !before[rel] {
+			t.hooked = append(t.hooked, rel)
+		}
+	}
+	if len(t.hooked) == 0 {
+		return nil
+	}
+	sort.Strings(t.hooked)
+
+	sink := dirSink{t.outputDir()}
+	if err := t.WriteManifest(sink, entries); err != nil {
+		return err
+	}
+	if key != nil {
+		return t.WriteAttestation(sink, entries, key)
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package skel
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRunHookScript(t *testing.T) {
+	dir, err := ioutil.TempDir("", "skel-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	skel := NewSkeleton("", SkeletonConfig{})
+	skel.KeyValues = map[string]string{"name": "x; rm -rf ~", "docs": "no"}
+	script := `
+		# portable steps
+		mkdir("bin")
+		target = concat("bin/", slug(name))
+		write(target, name)
+		docs ? mkdir("docs") : ""
+		exists(target) ? "" : fail("not written")
+	`
+	if err := skel.runHookScript("post-generate", script, dir); err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadFile(filepath.Join(dir, "bin", "x-rm-rf"))
+	if err != nil || string(data) != "x; rm -rf ~" {
+		t.Errorf("the file contains %q (%v), want the value as-is", data, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "docs")); err == nil {
+		t.Errorf("docs was made, while only the chosen branch is to be evaluated")
+	}
+
+	if err := skel.runHookScript("post-generate", `fail("stop")`, dir); err == nil {
+		t.Errorf("a failing statement did not fail the script")
+	}
+	if _, err := parseHookScript(`mkdir("a"`, nil); err == nil {
+		t.Errorf("an invalid statement was parsed")
+	}
+}
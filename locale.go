@@ -0,0 +1,103 @@
+package skel
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+// Selects among locale variants of files, like README.en.md and README.de.md,
+// of which the one matching the selected locale is written as README.md:
+//
+//	<locales param="language" fallback="en">en, de, fr</locales>
+type SkeletonLocales struct {
+	Param    string `xml:"param,attr"`    // variable holding the selected locale
+	Fallback string `xml:"fallback,attr"` // locale used when no variant matches the selected one
+	List     string `xml:",chardata"`     // locales of the variants, comma separated
+}
+
+// Returns the locale in a canonical form for comparison: lower case, with
+// dashes instead of underscores.
+func canonicalLocale(locale string) string {
+	return strings.ToLower(strings.Replace(strings.TrimSpace(locale), "_", "-", -1))
+}
+
+// Splits the locale off a file name like README.de.md or NOTICE.de, when it is
+// one of the given locales. Returns the name without the locale, and the
+// locale, which is empty when the name is not a variant.
+func localeVariant(name string, locales []string) (string, string) {
+	elements := strings.Split(name, ".")
+	// the locale is either the last element, or the one before the extension
+	for _, i := range []int{len(elements) - 2, len(elements) - 1} {
+		if i < 1 {
+			continue
+		}
+		for _, l := range locales {
+			if canonicalLocale(elements[i]) == canonicalLocale(l) {
+				canonical := append(append([]string{}, elements[:i]...), elements[i+1:]...)
+				return strings.Join(canonical, "."), canonicalLocale(l)
+			}
+		}
+	}
+	return name, ""
+}
+
+// Returns the variant matching the locale: the same locale, or one with the
+// same language, so de-AT selects de and de selects de-DE. Returns false when
+// none matches.
+func matchLocale(locale string, variants map[string]bool) (string, bool) {
+	locale = canonicalLocale(locale)
+	if locale == "" {
+		return "", false
+	}
+	if variants[locale] {
+		return locale, true
+	}
+	language := strings.SplitN(locale, "-", 2)[0]
+	if variants[language] {
+		return language, true
+	}
+	for v := range variants {
+		if v != "" && strings.SplitN(v, "-", 2)[0] == language {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// Returns the path of the skeleton file at rel as it is rendered, which drops
+// the locale from the name of a locale variant. Returns false when the file is
+// left out, because another variant of it is selected. A file without a
+// locale in its name is used when no variant matches.
+func (t Skeleton) localize(rel string, dir bool) (string, bool) {
+	l := t.Config.Locales
+	if l == nil || dir {
+		return rel, true
+	}
+	locales := listItems(l.List)
+	canonical, locale := localeVariant(filepath.Base(rel), locales)
+
+	// the variants of the same file are found next to it
+	variants := make(map[string]bool)
+	infos, _ := ioutil.ReadDir(filepath.Join(t.Location, filepath.Dir(rel)))
+	for _, info := range infos {
+		if name, loc := localeVariant(info.Name(), locales); name == canonical && !info.IsDir() {
+			variants[loc] = true
+		}
+	}
+	if len(variants) == 1 && locale == "" {
+		// not a variant, and has none
+		return rel, true
+	}
+
+	selected, ok := matchLocale(t.variables()[l.Param], variants)
+	if !ok {
+		if selected, ok = matchLocale(l.Fallback, variants); !ok {
+			selected = ""
+		}
+	}
+	if selected != locale {
+		return "", false
+	}
+	return filepath.Join(filepath.Dir(rel), canonical), true
+}
@@ -0,0 +1,133 @@
+package skel
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// Suffix of the lock file taken next to the output directory during
+	// generation, after a dot and the name of the directory.
+	LOCK_FILE = ".skel-generate.lock"
+)
+
+// Returned by lockFile when another process holds the lock.
+var errLocked = errors.New("locked")
+
+// A lock on an output directory, preventing concurrent runs of skel from
+// writing into the same directory. The lock is held by the operating system
+// while the lock file is open, so it is released when skel exits, even after
+// a crash or kill -9.
+type DirLock struct {
+	path string
+	f    *os.File
+}
+
+var (
+	heldLock sync.Mutex
+	held     = make(map[*DirLock]bool) // locks which are not released yet
+)
+
+// Returns the path of the lock file of the directory: .<name>.skel-generate.lock
+// next to it, so the directory itself need not exist.
+func lockPath(dir string) string {
+	dir = filepath.Clean(dir)
+	return filepath.Join(filepath.Dir(dir), "."+filepath.Base(dir)+LOCK_FILE)
+}
+
+// Reports whether another process holds the lock with the given lock file.
+func lockHeld(path string) bool {
+	f, err := lockFile(path)
+	if err == errLocked {
+		return true
+	}
+	if err == nil {
+		f.Close()
+	}
+	return false
+}
+
+// Takes the lock on the given directory, creating its parent if needed. When
+// another run of skel holds the lock, it is retried until the timeout has
+// passed.
+func LockDir(dir string, timeout time.Duration) (*DirLock, error) {
+	path := lockPath(dir)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+
+	host, _ := os.Hostname()
+	deadline := time.Now().Add(timeout)
+	waiting := false
+
+	for {
+		f, err := lockFile(path)
+		if err == nil {
+			// the lock file may have been removed by the run which released
+			// it, while waiting for it
+			info, err := f.Stat()
+			current, statErr := os.Stat(path)
+			if err != nil || statErr != nil || !os.SameFile(info, current) {
+				f.Close()
+				continue
+			}
+			f.Truncate(0)
+			fmt.Fprintf(f, "%d %s %s\n", os.Getpid(), host, time.Now().Format(time.RFC3339))
+			l := &DirLock{path, f}
+			heldLock.Lock()
+			held[l] = true
+			heldLock.Unlock()
+			return l, nil
+		}
+		if err != errLocked {
+			return nil, err
+		}
+
+		if time.Now().After(deadline) {
+			holder, _ := ioutil.ReadFile(path)
+			return nil, fmt.Errorf("'%s' is locked by another run of skel (%s)", dir, strings.TrimSpace(string(holder)))
+		}
+		if !waiting {
+			logf("Waiting for another run of skel to finish with '%s'...\n", dir)
+			waiting = true
+		}
+		time.Sleep(250 * time.Millisecond)
+	}
+}
+
+// Releases the lock. Releasing it again has no effect. The lock file is
+// removed while the lock is still held, so no other run takes a lock on a file
+// which is removed.
+func (l *DirLock) Unlock() error {
+	heldLock.Lock()
+	defer heldLock.Unlock()
+	if !held[l] {
+		return nil
+	}
+	delete(held, l)
+	err := os.Remove(l.path)
+	if closeErr := l.f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// Releases all locks which are still held.
+func releaseLocks() {
+	heldLock.Lock()
+	var locks []*DirLock
+	for l := range held {
+		locks = append(locks, l)
+	}
+	heldLock.Unlock()
+
+	for _, l := range locks {
+		l.Unlock()
+	}
+}
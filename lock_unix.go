@@ -0,0 +1,25 @@
+//go:build !windows
+
+package skel
+
+import (
+	"os"
+	"syscall"
+)
+
+// Opens the lock file, creating it if needed, and takes an exclusive lock on
+// it. Returns errLocked when another process holds the lock.
+func lockFile(path string) (*os.File, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		f.Close()
+		if err == syscall.EWOULDBLOCK {
+			return nil, errLocked
+		}
+		return nil, err
+	}
+	return f, nil
+}
@@ -0,0 +1,34 @@
+//go:build windows
+
+package skel
+
+import (
+	"os"
+	"syscall"
+)
+
+// Windows errors of opening a file which another process has open, or which
+// is about to be deleted.
+const (
+	ERROR_ACCESS_DENIED     = syscall.Errno(5)
+	ERROR_SHARING_VIOLATION = syscall.Errno(32)
+)
+
+// Opens the lock file, creating it if needed, without sharing it with other
+// processes but for deleting it. Returns errLocked when another process has
+// it open.
+func lockFile(path string) (*os.File, error) {
+	name, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return nil, err
+	}
+	h, err := syscall.CreateFile(name, syscall.GENERIC_READ|syscall.GENERIC_WRITE,
+		syscall.FILE_SHARE_DELETE, nil, syscall.OPEN_ALWAYS, syscall.FILE_ATTRIBUTE_NORMAL, 0)
+	if err == ERROR_SHARING_VIOLATION || err == ERROR_ACCESS_DENIED {
+		return nil, errLocked
+	}
+	if err != nil {
+		return nil, err
+	}
+	return os.NewFile(uintptr(h), path), nil
+}
@@ -0,0 +1,29 @@
+package skel
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+var (
+	// Where progress is reported, like waiting for a lock. Nothing is
+	// reported by default.
+	Log io.Writer = ioutil.Discard
+
+	// Whether details are reported to Log as well, like every file created and
+	// every temporary directory removed.
+	Verbose bool
+)
+
+// Reports progress to Log.
+func logf(format string, args ...interface{}) {
+	fmt.Fprintf(Log, format, args...)
+}
+
+// Reports details to Log, when Verbose is set.
+func debugf(format string, args ...interface{}) {
+	if Verbose {
+		fmt.Fprintf(Log, format, args...)
+	}
+}
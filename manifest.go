@@ -0,0 +1,193 @@
+package skel
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// Name of the manifest file written into every generated project.
+	MANIFEST_FILE = ".skel.lock"
+)
+
+// The manifest of a generated project, recording how it was generated: the
+// skeleton, the answers and the digests of all generated files. It allows the
+// project to be rendered again, for instance to detect drift.
+type Manifest struct {
+	SkelVersion string            `json:"skelVersion"`
+	Skeleton    ManifestSkeleton  `json:"skeleton"`
+	Answers     map[string]string `json:"answers"`
+	Random      ManifestRandom    `json:"random"`
+	Directories []string          `json:"directories"`
+	Files       map[string]string `json:"files"`              // slash separated path to sha256 digest
+	Existing    []string          `json:"existing,omitempty"` // paths which existed before, when merged into an existing directory
+	Hooked      []string          `json:"hooked,omitempty"`   // files which the post-generate hooks created, which are not verified
+}
+
+// The skeleton a project was generated from.
+type ManifestSkeleton struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+	Source  string `json:"source"`
+}
+
+// The values drawn once while generating: the random values, the date and the
+// user.
+type ManifestRandom struct {
+	UUID      string `json:"uuid"`
+	RandomHex string `json:"randomhex"`
+	Date      string `json:"date,omitempty"`
+	User      string `json:"user,omitempty"`
+}
+
+// Returns the sha256 digest of the data, as written in the manifest.
+func digest(data []byte) string {
+	return fmt.Sprintf("sha256:%x", sha256.Sum256(data))
+}
+
+// Returns the source of the skeleton as recorded in the manifest.
+func (t Skeleton) manifestSource() string {
+	source := t.Source
+	// only local skeletons have a path, not bundled skeletons and aliases
+	if _, err := os.Stat(source); err != nil {
+		return source
+	}
+	if abs, err := filepath.Abs(source); err == nil {
+		source = abs
+	}
+	return source
+}
+
+// Returns the manifest for the given generated entries.
+func (t Skeleton) Manifest(entries []Entry) Manifest {
+	source := t.manifestSource()
+
+	m := Manifest{
+		SkelVersion: VERSION,
+		Skeleton:    ManifestSkeleton{t.Config.Name, t.Config.Version, source},
+		Answers:     t.KeyValues,
+		Random:      ManifestRandom{t.uuid, t.randomhex, t.date, t.user},
+		Directories: []string{},
+		Files:       make(map[string]string),
+		Existing:    t.existing,
+		Hooked:      t.hooked,
+	}
+	for _, e := range entriesIn(entries, "") {
+		if e.Dir {
+			m.Directories = append(m.Directories, filepath.ToSlash(e.Path))
+		} else {
+			m.Files[filepath.ToSlash(e.Path)] = e.contentDigest()
+		}
+	}
+	return m
+}
+
+// Returns the slash separated paths the recorded generation created: its
+// directories and files, and the project directory itself, leaving out those
+// which existed before.
+func (m Manifest) Generated() []string {
+	var paths []string
+	for _, p := range append([]string{"."}, m.Directories...) {
+		if !contains(m.Existing, p) {
+			paths = append(paths, p)
+		}
+	}
+	for p := range m.Files {
+		if !contains(m.Existing, p) {
+			paths = append(paths, p)
+		}
+	}
+	return paths
+}
+
+// Returns the manifest for the given entries, as written.
+func (t Skeleton) manifestData(entries []Entry) ([]byte, error) {
+	data, err := json.MarshalIndent(t.Manifest(entries), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// Writes the manifest for the given entries to the sink.
+func (t Skeleton) WriteManifest(sink Sink, entries []Entry) error {
+	data, err := t.manifestData(entries)
+	if err != nil {
+		return err
+	}
+	return sink.WriteFile(MANIFEST_FILE, bytes.NewReader(data), 0644)
+}
+
+// Reads the manifest of the project in the given directory.
+func ReadManifest(dir string) (Manifest, error) {
+	m := Manifest{}
+	data, err := ioutil.ReadFile(filepath.Join(dir, MANIFEST_FILE))
+	if err != nil {
+		return m, err
+	}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return m, fmt.Errorf("invalid %s: %s", MANIFEST_FILE, err)
+	}
+	return m, nil
+}
+
+// Sets up the skeleton to render the project in dir again, exactly as
+// recorded in the manifest.
+func (t *Skeleton) Restore(m Manifest, dir string) {
+	t.KeyValues = m.Answers
+	t.uuid = m.Random.UUID
+	t.randomhex = m.Random.RandomHex
+	if m.Random.Date != "" {
+		t.date = m.Random.Date
+	}
+	if m.Random.User != "" {
+		t.user = m.Random.User
+	}
+	t.Outdir = filepath.Dir(dir)
+	t.outDirBase = filepath.Base(dir)
+	t.Dryrun = true
+}
+
+// Reports whether the path, relative to a project, is a file of skel itself
+// rather than generated output.
+func isSkelFile(rel string) bool {
+	switch filepath.ToSlash(rel) {
+	case MANIFEST_FILE, ANSWERS_FILE, ATTESTATION_FILE, ATTESTATION_SIGNATURE:
+		return true
+	}
+	// the lock of a directory generated within, while it is generated
+	return strings.HasSuffix(rel, LOCK_FILE)
+}
+
+// Lists all files in the project directory, relative to it, excluding the
+// files of skel itself.
+func projectFiles(dir string) (map[string]bool, error) {
+	files := make(map[string]bool)
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			// like that of a git init hook
+			if info.Name() == ".git" && path != dir {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if !isSkelFile(rel) {
+			files[filepath.ToSlash(rel)] = true
+		}
+		return nil
+	})
+	return files, err
+}
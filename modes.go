@@ -0,0 +1,59 @@
+package skel
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// Returns the permissions with which the entry is created: those of the
+// skeleton file or directory, or the defaults for entries without one, like a
+// generated .gitignore. Directories stay writable by their owner, so their
+// contents can be generated.
+func (e Entry) perm() os.FileMode {
+	perm := e.Mode.Perm()
+	switch {
+	case e.Dir && perm == 0:
+		return 0755
+	case e.Dir:
+		return perm | 0700
+	case perm == 0:
+		return 0644
+	}
+	return perm
+}
+
+// Returns the digest of the file at path as recorded in the manifest: that of
+// its contents, or of its target for a symbolic link.
+func pathDigest(path string) (string, error) {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return "", err
+	}
+	if info.Mode()&os.ModeSymlink != 0 {
+		target, err := os.Readlink(path)
+		if err != nil {
+			return "", err
+		}
+		return digest([]byte(target)), nil
+	}
+	return fileDigest(path)
+}
+
+// Sets the modification times of the generated entries in root to those of
+// the skeleton files and directories they were generated from. Symbolic links,
+// entries without a skeleton file and existing files which were kept, as
+// given by the resolutions, are left alone.
+func setModTimes(root string, entries []Entry, resolutions map[string]string) error {
+	// in reverse, so children are done before their parents, which they
+	// would touch otherwise
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		if e.ModTime.IsZero() || e.Link != "" || resolutions[e.Path] == RESOLVE_SKIP || resolutions[e.Path] == RESOLVE_KEEP_BOTH {
+			continue
+		}
+		if err := os.Chtimes(filepath.Join(root, e.Path), e.ModTime, e.ModTime); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package skel
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Splits a name into its separate words. Words are separated by anything not
+// being a letter or digit, and by lower-to-upper case transitions, so that
+// "My cool-Project", "myCoolProject" and "my_cool_project" all result in
+// the words "my", "cool" and "project".
+func splitWords(s string) []string {
+	var words []string
+	var current []rune
+
+	flush := func() {
+		if len(current) > 0 {
+			words = append(words, strings.ToLower(string(current)))
+			current = nil
+		}
+	}
+
+	runes := []rune(s)
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			flush()
+			continue
+		}
+		if unicode.IsUpper(r) && len(current) > 0 {
+			prev := runes[i-1]
+			// "myProject" splits before the P, "HTTPServer" splits before the S.
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				flush()
+			}
+		}
+		current = append(current, r)
+	}
+	flush()
+
+	return words
+}
+
+// Uppercases the first letter of the given word.
+func capitalize(word string) string {
+	runes := []rune(word)
+	if len(runes) == 0 {
+		return word
+	}
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes)
+}
+
+// Converts the name to a lowercase, dash separated slug ("my-cool-project").
+func slugCase(s string) string {
+	return strings.Join(splitWords(s), "-")
+}
+
+// Converts the name to lower camel case ("myCoolProject").
+func camelCase(s string) string {
+	words := splitWords(s)
+	for i := 1; i < len(words); i++ {
+		words[i] = capitalize(words[i])
+	}
+	return strings.Join(words, "")
+}
+
+// Converts the name to upper camel case ("MyCoolProject").
+func pascalCase(s string) string {
+	words := splitWords(s)
+	for i := range words {
+		words[i] = capitalize(words[i])
+	}
+	return strings.Join(words, "")
+}
+
+// Converts the name to lowercase, underscore separated words ("my_cool_project").
+func snakeCase(s string) string {
+	return strings.Join(splitWords(s), "_")
+}
+
+// Converts the name to a prefix usable for environment variables
+// ("MY_COOL_PROJECT").
+func envPrefix(s string) string {
+	return strings.ToUpper(snakeCase(s))
+}
+
+// Returns the derived variants of a parameter value, keyed by the full
+// variable name, e.g. "projectname.slug".
+func derivedVariants(name, value string) map[string]string {
+	return map[string]string{
+		name + ".slug":      slugCase(value),
+		name + ".camel":     camelCase(value),
+		name + ".pascal":    pascalCase(value),
+		name + ".snake":     snakeCase(value),
+		name + ".envprefix": envPrefix(value),
+	}
+}
+
+// Normalizations which a parameter can declare for its input, with the
+// normalize attribute (e.g. normalize="trim,lower,dashes").
+var inputNormalizers = map[string]func(string) string{
+	"trim":     strings.TrimSpace,
+	"lower":    strings.ToLower,
+	"upper":    strings.ToUpper,
+	"collapse": func(s string) string { return strings.Join(strings.Fields(s), " ") },
+	"dashes":   func(s string) string { return strings.Join(strings.Fields(s), "-") },
+	"nfc":      normalizeNFC,
+}
+
+// Returns the normalization steps of the comma separated spec, or an error
+// when one of them is unknown.
+func normalizeSteps(spec string) ([]string, error) {
+	var steps []string
+	for _, step := range strings.Split(spec, ",") {
+		step = strings.TrimSpace(step)
+		if step == "" {
+			continue
+		}
+		if _, ok := inputNormalizers[step]; !ok {
+			return nil, fmt.Errorf("unknown normalization '%s'", step)
+		}
+		steps = append(steps, step)
+	}
+	return steps, nil
+}
+
+// Applies the normalizations of the comma separated spec to the value, in
+// the order given. Unknown normalizations are ignored; they are refused when
+// the skeleton is parsed.
+func NormalizeInput(value, spec string) string {
+	steps, _ := normalizeSteps(spec)
+	for _, step := range steps {
+		value = inputNormalizers[step](value)
+	}
+	return value
+}
@@ -0,0 +1,78 @@
+package skel
+
+import (
+	"fmt"
+)
+
+// Hangul syllable composition constants, see chapter 3.12 of the Unicode
+// standard.
+const (
+	hangulSBase  = 0xAC00
+	hangulLBase  = 0x1100
+	hangulVBase  = 0x1161
+	hangulTBase  = 0x11A7
+	hangulLCount = 19
+	hangulVCount = 21
+	hangulTCount = 28
+	hangulNCount = hangulVCount * hangulTCount
+	hangulSCount = hangulLCount * hangulNCount
+)
+
+// Returns the composition of the starter a with the character b, if any.
+func composePair(a, b rune) (rune, bool) {
+	// Hangul leading consonant + vowel
+	if a >= hangulLBase && a < hangulLBase+hangulLCount && b >= hangulVBase && b < hangulVBase+hangulVCount {
+		return hangulSBase + ((a-hangulLBase)*hangulVCount+(b-hangulVBase))*hangulTCount, true
+	}
+	// Hangul LV syllable + trailing consonant
+	if a >= hangulSBase && a < hangulSBase+hangulSCount && (a-hangulSBase)%hangulTCount == 0 &&
+		b > hangulTBase && b < hangulTBase+hangulTCount {
+		return a + (b - hangulTBase), true
+	}
+	c, ok := nfcCompositions[[2]rune{a, b}]
+	return c, ok
+}
+
+// Normalizes s to Unicode Normalization Form C, composing decomposed
+// characters such as "e" followed by a combining acute accent (as produced by
+// macOS for file names) into their precomposed form "é".
+//
+// Combining characters are expected in canonical order, which is the case for
+// all text produced by a canonical decomposition.
+func normalizeNFC(s string) string {
+	out := make([]rune, 0, len(s))
+	starter := -1   // index in out of the last starter
+	lastClass := -1 // combining class of the last character after the starter, -1 if none
+
+	for _, r := range s {
+		class := int(combiningClasses[r])
+		// a character is blocked from the starter when a character of the
+		// same or a higher class, or another starter, is in between
+		if starter >= 0 && (lastClass == -1 || (lastClass != 0 && lastClass < class)) {
+			if c, ok := composePair(out[starter], r); ok {
+				out[starter] = c
+				continue
+			}
+		}
+		if class == 0 {
+			starter = len(out)
+			lastClass = -1
+		} else {
+			lastClass = class
+		}
+		out = append(out, r)
+	}
+
+	return string(out)
+}
+
+// Records the rendered (and normalized) path of a source path. Returns an
+// error when another source path already rendered to the same path, which
+// can happen when parameter values only differ in their normalization.
+func (t Skeleton) claimPath(rendered, source string) error {
+	if other, ok := t.rendered[rendered]; ok && other != source {
+		return fmt.Errorf("'%s' and '%s' both result in '%s'", other, source, rendered)
+	}
+	t.rendered[rendered] = source
+	return nil
+}
@@ -0,0 +1,82 @@
+package skel
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// Environment variable holding a list of workspace root directories, which
+	// are offered as output directories in the interactive picker.
+	ENV_WORKSPACES = "SKEL_WORKSPACES"
+
+	// Name of the state file holding the previously chosen output directory.
+	STATE_OUTDIR = "outdir"
+)
+
+// Reports whether the list contains the given string.
+func contains(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the configured workspace roots, from the environment and the user
+// configuration, in that order.
+func WorkspaceRoots() []string {
+	var roots []string
+	for _, root := range filepath.SplitList(os.Getenv(ENV_WORKSPACES)) {
+		if root != "" {
+			roots = append(roots, root)
+		}
+	}
+	for _, root := range LoadUserConfig().Workspaces {
+		if root = strings.TrimSpace(root); root != "" {
+			roots = append(roots, expandHome(root))
+		}
+	}
+	return roots
+}
+
+// Checks whether files can be created in the given directory. When the
+// directory does not exist yet, its nearest existing parent is checked since
+// that is where the directory will be created.
+func CheckWritable(dir string) error {
+	existing, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+
+	for {
+		stat, err := os.Stat(existing)
+		if err == nil {
+			if !stat.IsDir() {
+				return fmt.Errorf("'%s' is not a directory", existing)
+			}
+			break
+		}
+		if !os.IsNotExist(err) {
+			return err
+		}
+		parent := filepath.Dir(existing)
+		if parent == existing {
+			return fmt.Errorf("no existing parent directory for '%s'", dir)
+		}
+		existing = parent
+	}
+
+	probe, err := ioutil.TempFile(existing, ".skel-probe")
+	if err != nil {
+		return fmt.Errorf("'%s' is not writable: %s", existing, err)
+	}
+	probe.Close()
+	os.Remove(probe.Name())
+
+	return nil
+}
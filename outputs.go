@@ -0,0 +1,111 @@
+package skel
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Maps a directory of the skeleton to another destination root than the
+// output directory, like <output path="docs" root="${docsdir}"/>.
+type SkeletonOutput struct {
+	Path string `xml:"path,attr"` // directory within the skeleton
+	Root string `xml:"root,attr"` // destination directory, substituted
+}
+
+// Returns the destination root of the configured output, with variables
+// substituted.
+func (t Skeleton) outputRoot(o SkeletonOutput) (string, error) {
+	root, err := t.findReplace(o.Root)
+	if err != nil {
+		return "", fmt.Errorf("invalid root of output '%s': %s", o.Path, err)
+	}
+	root = strings.TrimSpace(root)
+	if root == "" {
+		return "", fmt.Errorf("no root given for output '%s'", o.Path)
+	}
+	return filepath.Abs(expandHome(root))
+}
+
+// Assigns the entries rendered from a directory given in the <outputs> of the
+// skeleton to the destination root of that directory. Their paths become
+// relative to that root, and the directory itself is left out.
+func (t Skeleton) route(entries []Entry) ([]Entry, error) {
+	for _, o := range t.Config.Outputs {
+		src := filepath.Clean(filepath.FromSlash(o.Path))
+		if !withinDir(".", src) || src == "." {
+			return nil, fmt.Errorf("output path '%s' is not a directory within the skeleton", o.Path)
+		}
+		root, err := t.outputRoot(o)
+		if err != nil {
+			return nil, err
+		}
+
+		// the rendered name of the directory, which its contents start with
+		prefix := ""
+		for _, e := range entries {
+			if e.Dir && e.Root == "" && e.Source == filepath.Join(t.Location, src) {
+				prefix = e.Path
+			}
+		}
+		if prefix == "" {
+			return nil, fmt.Errorf("output path '%s' is not a directory within the skeleton", o.Path)
+		}
+
+		var routed []Entry
+		for _, e := range entries {
+			if e.Root == "" && e.Path == prefix {
+				continue
+			}
+			if rel, err := filepath.Rel(prefix, e.Path); e.Root == "" && err == nil && withinDir(".", rel) {
+				e.Path = rel
+				e.Root = root
+			}
+			routed = append(routed, e)
+		}
+		entries = routed
+	}
+	return entries, nil
+}
+
+// Returns the distinct destination roots of the entries besides the output
+// directory, sorted.
+func outputRoots(entries []Entry) []string {
+	var roots []string
+	for _, e := range entries {
+		if e.Root != "" && !contains(roots, e.Root) {
+			roots = append(roots, e.Root)
+		}
+	}
+	sort.Strings(roots)
+	return roots
+}
+
+// Returns the entries destined for the given root; an empty root denotes the
+// output directory.
+func entriesIn(entries []Entry, root string) []Entry {
+	var in []Entry
+	for _, e := range entries {
+		if e.Root == root {
+			in = append(in, e)
+		}
+	}
+	return in
+}
+
+// Writes the entries destined for the given root. The entries are staged
+// first, and then moved into the root, which may exist already. The changes
+// are recorded in the journal.
+func (t Skeleton) writeRoot(root string, entries []Entry, resolutions map[string]string, j *journal) error {
+	staging, err := temps.Dir("skel-staging")
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if err := t.writeEntry(dirSink{staging}, e); err != nil {
+			return err
+		}
+	}
+	return mergeTree(staging, root, entries, resolutions, j)
+}
@@ -0,0 +1,99 @@
+package skel
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Owner of generated files and directories. A value of -1 leaves the user or
+// group unchanged.
+type Owner struct {
+	Uid, Gid int
+}
+
+// Parses an owner specification in the form user[:group], where the user and
+// group are either names or numeric ids. When only a user name is given, its
+// primary group is used.
+func ParseOwner(spec string) (Owner, error) {
+	owner := Owner{-1, -1}
+
+	userPart, groupPart := spec, ""
+	if i := strings.Index(spec, ":"); i >= 0 {
+		userPart, groupPart = spec[:i], spec[i+1:]
+	}
+
+	if userPart != "" {
+		if uid, err := strconv.Atoi(userPart); err == nil {
+			owner.Uid = uid
+		} else {
+			u, err := user.Lookup(userPart)
+			if err != nil {
+				return owner, err
+			}
+			if owner.Uid, err = strconv.Atoi(u.Uid); err != nil {
+				return owner, fmt.Errorf("user '%s' has no numeric id", userPart)
+			}
+			if groupPart == "" {
+				owner.Gid, _ = strconv.Atoi(u.Gid)
+			}
+		}
+	}
+
+	if groupPart != "" {
+		if gid, err := strconv.Atoi(groupPart); err == nil {
+			owner.Gid = gid
+		} else {
+			g, err := user.LookupGroup(groupPart)
+			if err != nil {
+				return owner, err
+			}
+			if owner.Gid, err = strconv.Atoi(g.Gid); err != nil {
+				return owner, fmt.Errorf("group '%s' has no numeric id", groupPart)
+			}
+		}
+	}
+
+	return owner, nil
+}
+
+// Changes the owner of the output directory, the generated entries and the
+// manifest. Symbolic links themselves are changed, not what they point to.
+// Existing files which were kept, as given by the resolutions of the output
+// directory and of the roots, are left alone, and so are the directories of
+// the output directory which existed before.
+func (t Skeleton) Chown(entries []Entry, owner Owner, resolutions map[string]string, rootResolutions map[string]map[string]string) error {
+	paths := []string{filepath.Join(t.outputDir(), MANIFEST_FILE)}
+	if !contains(t.existing, ".") {
+		paths = append(paths, t.outputDir())
+	}
+	for _, name := range []string{ANSWERS_FILE, ATTESTATION_FILE, ATTESTATION_SIGNATURE} {
+		if _, err := os.Lstat(filepath.Join(t.outputDir(), name)); err == nil {
+			paths = append(paths, filepath.Join(t.outputDir(), name))
+		}
+	}
+	for _, e := range entries {
+		res := resolutions[e.Path]
+		if e.Root != "" {
+			res = rootResolutions[e.Root][e.Path]
+		}
+		if res == RESOLVE_SKIP || res == RESOLVE_KEEP_BOTH {
+			continue
+		}
+		if e.Root != "" {
+			paths = append(paths, filepath.Join(e.Root, e.Path))
+		} else if !e.Dir || !contains(t.existing, filepath.ToSlash(e.Path)) {
+			paths = append(paths, filepath.Join(t.outputDir(), e.Path))
+		}
+	}
+
+	for _, path := range paths {
+		if err := os.Lchown(path, owner.Uid, owner.Gid); err != nil {
+			return err
+		}
+	}
+	return nil
+}
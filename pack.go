@@ -0,0 +1,88 @@
+package skel
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Bundles the skeleton in dir into the archive out, a zip or tar.gz file by
+// its name, to be distributed and used as -in. The configuration is checked
+// first. Entries are written like archive output, so packing the same skeleton
+// results in an identical archive; .git and the files of skel, like
+// .skel.lock, are left out. Returns the number of files packed.
+func Pack(dir, out string) (int, error) {
+	if stat, err := os.Stat(dir); err != nil {
+		return 0, err
+	} else if !stat.IsDir() {
+		return 0, fmt.Errorf("'%s' is not a directory", dir)
+	}
+	if _, err := ParseSkeleton(dir); err != nil {
+		return 0, fmt.Errorf("invalid skeleton: %s", err)
+	}
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	kind := archiveKind(out)
+	if kind == "" {
+		return 0, fmt.Errorf("'%s' is not a .zip, .tar.gz or .tgz file", out)
+	}
+	if abs, err := filepath.Abs(out); err == nil && withinDir(dir, abs) {
+		return 0, fmt.Errorf("the archive cannot be written within the skeleton")
+	}
+
+	sink, err := NewArchiveSink(kind, out)
+	if err != nil {
+		return 0, err
+	}
+	files, err := packTree(dir, sink)
+	if err != nil {
+		abandon(sink)
+		os.Remove(out)
+		return 0, err
+	}
+	if err := sink.Close(); err != nil {
+		os.Remove(out)
+		return 0, err
+	}
+	return files, nil
+}
+
+// Writes the files, directories and links in dir to the sink.
+func packTree(dir string, sink Sink) (int, error) {
+	files := 0
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil || rel == "." {
+			return err
+		}
+		if info.IsDir() && info.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		if isSkelFile(rel) {
+			return nil
+		}
+
+		e := Entry{Path: rel, Source: path, Dir: info.IsDir(), Mode: info.Mode(), Raw: true}
+		switch {
+		case e.Dir:
+			return sink.MkdirAll(e.Path, e.perm())
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			files++
+			return sink.Symlink(e.Path, link)
+		case !info.Mode().IsRegular():
+			return fmt.Errorf("'%s' is not a file, directory or link", rel)
+		}
+		files++
+		debugf("Packing '%s'\n", rel)
+		return writeExtracted(sink, e)
+	})
+	return files, err
+}
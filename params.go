@@ -0,0 +1,148 @@
+package skel
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Returns the value in a JSON answer file as a string. Lists are joined with
+// commas, like they are entered.
+func jsonValue(v interface{}) (string, error) {
+	switch value := v.(type) {
+	case string:
+		return value, nil
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64), nil
+	case bool:
+		return strconv.FormatBool(value), nil
+	case []interface{}:
+		var items []string
+		for _, item := range value {
+			s, err := jsonValue(item)
+			if err != nil {
+				return "", err
+			}
+			items = append(items, s)
+		}
+		return strings.Join(items, ", "), nil
+	}
+	return "", fmt.Errorf("unsupported value %v", v)
+}
+
+// Decodes a scalar YAML value: quotes are removed, and a trailing
+// comment of a plain value. The counterpart of yamlScalar.
+func yamlValue(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	switch {
+	case strings.HasPrefix(s, `"`):
+		return strconv.Unquote(s)
+	case strings.HasPrefix(s, "'"):
+		if len(s) < 2 || !strings.HasSuffix(s, "'") {
+			return "", fmt.Errorf("unterminated string %s", s)
+		}
+		return strings.Replace(s[1:len(s)-1], "''", "'", -1), nil
+	}
+	if i := strings.Index(s, " #"); i >= 0 {
+		s = strings.TrimSpace(s[:i])
+	}
+	return s, nil
+}
+
+// Parses answers in YAML. Only what is needed for answers is supported: a
+// mapping of names to scalars, or to lists of scalars, either as [a, b] or
+// as a block of "- a" lines.
+func parseYAMLAnswers(data []byte) (map[string]string, error) {
+	answers := make(map[string]string)
+	var list string // the key of the block sequence being read, if any
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") || trimmed == "---" {
+			continue
+		}
+
+		if strings.HasPrefix(trimmed, "- ") || trimmed == "-" {
+			if list == "" {
+				return nil, fmt.Errorf("line %d: list item without a name", n)
+			}
+			item, err := yamlValue(strings.TrimPrefix(trimmed, "-"))
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %s", n, err)
+			}
+			if answers[list] != "" {
+				answers[list] += ", "
+			}
+			answers[list] += item
+			continue
+		}
+		if line != trimmed {
+			return nil, fmt.Errorf("line %d: nested mappings are not supported", n)
+		}
+
+		i := strings.Index(line, ":")
+		if i <= 0 {
+			return nil, fmt.Errorf("line %d: expected name: value", n)
+		}
+		key, err := yamlValue(line[:i])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s", n, err)
+		}
+		value := strings.TrimSpace(line[i+1:])
+		list = ""
+		switch {
+		case value == "" || strings.HasPrefix(value, "#"):
+			list = key
+			answers[key] = ""
+		case strings.HasPrefix(value, "[") && strings.HasSuffix(value, "]"):
+			var items []string
+			for _, item := range strings.Split(value[1:len(value)-1], ",") {
+				s, err := yamlValue(item)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: %s", n, err)
+				}
+				if s != "" {
+					items = append(items, s)
+				}
+			}
+			answers[key] = strings.Join(items, ", ")
+		default:
+			if answers[key], err = yamlValue(value); err != nil {
+				return nil, fmt.Errorf("line %d: %s", n, err)
+			}
+		}
+	}
+	return answers, scanner.Err()
+}
+
+// Reads the answers in the JSON or YAML file, by its extension.
+func ReadParamFile(path string) (map[string]string, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return parseYAMLAnswers(data)
+	case ".json":
+		values := make(map[string]interface{})
+		if err := json.Unmarshal(data, &values); err != nil {
+			return nil, err
+		}
+		answers := make(map[string]string)
+		for k, v := range values {
+			if answers[k], err = jsonValue(v); err != nil {
+				return nil, fmt.Errorf("%s: %s", k, err)
+			}
+		}
+		return answers, nil
+	}
+	return nil, fmt.Errorf("unknown format, expected a .json, .yaml or .yml file")
+}
@@ -0,0 +1,158 @@
+package skel
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Types of parameters, as given by their type attribute.
+const (
+	PARAM_STRING = "string" // free text (the default)
+	PARAM_BOOL   = "bool"   // yes or no, recorded as true or false
+	PARAM_INT    = "int"    // a whole number
+	PARAM_ENUM   = "enum"   // one of the <choices>
+)
+
+// Checks the type, choices and regex of the parameter, as declared in the
+// skeleton configuration.
+func (p SkeletonParams) checkDeclaration() error {
+	switch p.Type {
+	case "", PARAM_STRING, PARAM_BOOL, PARAM_INT:
+	case PARAM_ENUM:
+		if p.Choices == nil {
+			return fmt.Errorf("type %s needs <choices>", PARAM_ENUM)
+		}
+	default:
+		return fmt.Errorf("unknown type '%s', expected %s, %s, %s or %s", p.Type, PARAM_STRING, PARAM_BOOL, PARAM_INT, PARAM_ENUM)
+	}
+	if p.Type == PARAM_BOOL && p.List {
+		return fmt.Errorf("a %s cannot be a list", PARAM_BOOL)
+	}
+	if _, err := regexp.Compile(p.Regex); err != nil {
+		return fmt.Errorf("invalid regex: %s", err)
+	}
+	return nil
+}
+
+// Returns the bool in the canonical form true or false, accepting answers like
+// yes, no, y and n.
+func parseBool(value string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "true", "t", "yes", "y", "on", "1":
+		return "true", nil
+	case "false", "f", "no", "n", "off", "0":
+		return "false", nil
+	}
+	return "", fmt.Errorf("'%s' is not yes or no", value)
+}
+
+// Checks a single value, or a single element of a list, against the type and
+// regex of the parameter. Returns the value in its canonical form.
+func (p SkeletonParams) checkItem(value string) (string, error) {
+	switch p.Type {
+	case PARAM_BOOL:
+		return parseBool(value)
+	case PARAM_INT:
+		n, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			return "", fmt.Errorf("'%s' is not a whole number", value)
+		}
+		value = strconv.Itoa(n)
+	}
+	if p.Regex != "" && !regexp.MustCompile(`^(?:`+p.Regex+`)$`).MatchString(value) {
+		return "", fmt.Errorf("'%s' does not match %s", value, p.Regex)
+	}
+	return value, nil
+}
+
+// Checks the value of the parameter against its declaration: a required
+// parameter must have a value, and a value must be of the type of the
+// parameter and match its regex. Every element of a list is checked. Returns
+// the value in its canonical form, like true for yes. Choices are checked by
+// CheckValue.
+func (p SkeletonParams) Check(value string) (string, error) {
+	if strings.TrimSpace(value) == "" {
+		if p.Required {
+			return "", fmt.Errorf("a value is required")
+		}
+		return value, nil
+	}
+	if !p.List {
+		return p.checkItem(value)
+	}
+	var items []string
+	for _, item := range listItems(value) {
+		item, err := p.checkItem(item)
+		if err != nil {
+			return "", err
+		}
+		items = append(items, item)
+	}
+	return strings.Join(items, ", "), nil
+}
+
+// Checks the value of the parameter like Check, and whether it is one of its
+// choices, if it has any. Every element of a list must be one of them.
+func (t Skeleton) CheckValue(p SkeletonParams, value string) (string, error) {
+	value, err := p.Check(value)
+	if err != nil || p.Choices == nil || (value == "" && !p.Required) {
+		return value, err
+	}
+	options, err := t.ChoiceOptions(*p.Choices)
+	if err != nil {
+		return "", fmt.Errorf("unable to list the choices: %s", err)
+	}
+	items := []string{value}
+	if p.List {
+		items = listItems(value)
+	}
+	for _, item := range items {
+		if !contains(options, item) {
+			return "", fmt.Errorf("'%s' is not one of: %s", item, strings.Join(options, ", "))
+		}
+	}
+	return value, nil
+}
+
+// Returns the default value of the parameter, substituted with the values
+// given so far, so a default can be derived from an earlier answer.
+func (t Skeleton) DefaultValue(p SkeletonParams) (string, error) {
+	if p.Default == "" {
+		return "", nil
+	}
+	value, err := t.findReplace(p.Default)
+	if err != nil {
+		return "", fmt.Errorf("invalid default of parameter '%s': %s", p.Name, err)
+	}
+	return value, nil
+}
+
+// Completes and checks the values of the parameters: missing values are
+// replaced by their defaults, and every value is checked with CheckValue.
+// Returns the values in their canonical form.
+func (t *Skeleton) checkValues(values map[string]string) (map[string]string, error) {
+	checked := make(map[string]string)
+	for k, v := range values {
+		checked[k] = v
+	}
+	// defaults may refer to earlier values
+	t.KeyValues = checked
+	for _, p := range t.Config.Parameters {
+		value, ok := checked[p.Name]
+		if !ok {
+			def, err := t.DefaultValue(p)
+			if err != nil {
+				return nil, err
+			}
+			value = def
+		}
+		value, err := t.CheckValue(p, value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for parameter '%s': %s", p.Name, err)
+		}
+		checked[p.Name] = value
+	}
+	return checked, nil
+}
@@ -0,0 +1,34 @@
+package skel
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// Source of all randomness used in generated output. It is seeded with the
+// current time, unless reseeded with SeedRandom, which makes the random values
+// deterministic.
+var random = rand.New(rand.NewSource(time.Now().UnixNano()))
+
+// Reseeds the random source with the given seed.
+func SeedRandom(seed int64) {
+	random = rand.New(rand.NewSource(seed))
+}
+
+// Returns a random (version 4) UUID.
+func randomUUID() string {
+	b := make([]byte, 16)
+	random.Read(b)
+	b[6] = (b[6] & 0x0f) | 0x40 // version 4
+	b[8] = (b[8] & 0x3f) | 0x80 // RFC 4122 variant
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// Returns a string of n random hexadecimal characters.
+func randomHex(n int) string {
+	b := make([]byte, (n+1)/2)
+	random.Read(b)
+	return fmt.Sprintf("%x", b)[:n]
+}
@@ -0,0 +1,146 @@
+package skel
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	// Environment variable holding the directory of installed skeletons.
+	ENV_HOME = "SKEL_HOME"
+
+	// Prefix of -in values naming an installed skeleton, like "installed:svc".
+	INSTALLED_PREFIX = "installed:"
+)
+
+// A skeleton installed in the registry.
+type InstalledSkeleton struct {
+	Name   string         // name under which it is installed
+	Dir    string         // directory holding the skeleton
+	Config SkeletonConfig // its configuration
+}
+
+// Returns the directory holding the installed skeletons: $SKEL_HOME, the
+// <registry> of the user configuration, or ~/.skel.
+func RegistryDir() string {
+	if dir := os.Getenv(ENV_HOME); dir != "" {
+		return expandHome(dir)
+	}
+	if dir := strings.TrimSpace(LoadUserConfig().Registry); dir != "" {
+		return expandHome(dir)
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		// no home directory at all, resort to the temp directory
+		home = filepath.Join(os.TempDir(), "skel-"+ENV_HOME)
+	}
+	return filepath.Join(home, ".skel")
+}
+
+// Checks whether the name can be that of an installed skeleton: a single,
+// non-hidden path element.
+func checkInstalledName(name string) error {
+	if name == "" || strings.ContainsAny(name, `/\:`) || strings.HasPrefix(name, "-") || strings.HasPrefix(name, ".") {
+		return fmt.Errorf("invalid skeleton name '%s'", name)
+	}
+	return nil
+}
+
+// Returns the skeletons installed in the registry, sorted by name. Directories
+// without a valid config.xml are left out.
+func InstalledSkeletons() ([]InstalledSkeleton, error) {
+	infos, err := ioutil.ReadDir(RegistryDir())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var installed []InstalledSkeleton
+	for _, info := range infos {
+		if !info.IsDir() || checkInstalledName(info.Name()) != nil {
+			continue
+		}
+		dir := filepath.Join(RegistryDir(), info.Name())
+		t, err := ParseSkeleton(dir)
+		if err != nil {
+			debugf("Ignoring '%s': %s\n", dir, err)
+			continue
+		}
+		installed = append(installed, InstalledSkeleton{info.Name(), dir, t.Config})
+	}
+	sort.Slice(installed, func(i, j int) bool { return installed[i].Name < installed[j].Name })
+	return installed, nil
+}
+
+// Returns the directory of the installed skeleton with the given name.
+func installedDir(name string) (string, error) {
+	if err := checkInstalledName(name); err != nil {
+		return "", err
+	}
+	dir := filepath.Join(RegistryDir(), name)
+	if _, err := os.Stat(filepath.Join(dir, "config.xml")); err != nil {
+		return "", fmt.Errorf("no installed skeleton '%s'", name)
+	}
+	return dir, nil
+}
+
+// Installs the skeleton at the given location, which is anything Load opens,
+// in the registry under the given name. Without a name, the skeleton is named
+// after the slug of its <name>. An installed skeleton of the same name is only
+// replaced when replace is set. Returns the name.
+func Install(in, name string, replace bool) (string, error) {
+	t, err := Load(in)
+	if err != nil {
+		return "", err
+	}
+	if name == "" {
+		name = slugCase(t.Config.Name)
+	}
+	if err := checkInstalledName(name); err != nil {
+		return "", err
+	}
+
+	dir := filepath.Join(RegistryDir(), name)
+	if _, err := os.Stat(dir); err == nil && !replace {
+		return "", fmt.Errorf("a skeleton '%s' is installed already", name)
+	}
+	if err := os.MkdirAll(RegistryDir(), 0755); err != nil {
+		return "", err
+	}
+
+	// copied next to its destination first, so a half copied skeleton is
+	// never installed
+	staging, err := ioutil.TempDir(RegistryDir(), ".install-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(staging)
+	copied := filepath.Join(staging, name)
+	if err := copyTree(t.Location, copied); err != nil {
+		return "", err
+	}
+	// the history of a cloned repository is of no use
+	if err := os.RemoveAll(filepath.Join(copied, ".git")); err != nil {
+		return "", err
+	}
+
+	if err := os.RemoveAll(dir); err != nil {
+		return "", err
+	}
+	return name, os.Rename(copied, dir)
+}
+
+// Removes the installed skeleton with the given name from the registry.
+func Uninstall(name string) error {
+	dir, err := installedDir(name)
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(dir)
+}
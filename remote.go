@@ -0,0 +1,266 @@
+package skel
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// A directory on a remote host, given as sftp://[user@]host[:port]/path or
+// ssh://[user@]host[:port]/path. A path starting with /~/ is relative to the
+// home directory of the user.
+type RemoteTarget struct {
+	Scheme string
+	User   string
+	Host   string
+	Port   string
+	Path   string
+}
+
+// Parses the output location as a remote target. Reports false when it is not
+// one.
+func remoteTarget(out string) (RemoteTarget, bool, error) {
+	if !strings.HasPrefix(out, "sftp://") && !strings.HasPrefix(out, "ssh://") {
+		return RemoteTarget{}, false, nil
+	}
+	u, err := url.Parse(out)
+	if err != nil {
+		return RemoteTarget{}, true, err
+	}
+	if u.Hostname() == "" {
+		return RemoteTarget{}, true, fmt.Errorf("no host in '%s'", out)
+	}
+
+	r := RemoteTarget{Scheme: u.Scheme, Host: u.Hostname(), Port: u.Port(), Path: u.Path}
+	if u.User != nil {
+		r.User = u.User.Username()
+	}
+	switch {
+	case r.Path == "" || r.Path == "/~":
+		r.Path = "."
+	case strings.HasPrefix(r.Path, "/~/"):
+		r.Path = strings.TrimPrefix(r.Path, "/~/")
+	}
+	return r, true, nil
+}
+
+// Returns the target as an URL, like it is given on the command line.
+func (r RemoteTarget) String() string {
+	host := r.Host
+	if r.User != "" {
+		host = r.User + "@" + host
+	}
+	if r.Port != "" {
+		host += ":" + r.Port
+	}
+	p := r.Path
+	if !path.IsAbs(p) {
+		p = path.Join("/~", p)
+	}
+	return r.Scheme + "://" + host + p
+}
+
+// Writes the output to a directory on a remote host given as ssh://. The
+// output is streamed as a tar to the system's ssh client, which extracts it on
+// the remote host, so nothing but a shell and tar is needed there.
+type sshSink struct {
+	*tarSink
+	cmd    *exec.Cmd
+	stderr *bytes.Buffer
+}
+
+// Creates a sink writing into the directory dir within the target directory
+// on the remote host. The directory must not exist yet.
+func NewSSHSink(r RemoteTarget, dir string) (Sink, error) {
+	remoteDir := path.Join(r.Path, dir)
+	command := fmt.Sprintf("mkdir -p %s && mkdir %s && tar -xzf - -C %s",
+		shellQuote(r.Path), shellQuote(remoteDir), shellQuote(remoteDir))
+
+	var args []string
+	if r.Port != "" {
+		args = append(args, "-p", r.Port)
+	}
+	host := r.Host
+	if r.User != "" {
+		host = r.User + "@" + host
+	}
+	args = append(args, "--", host, command)
+
+	cmd := exec.Command("ssh", args...)
+	stderr := new(bytes.Buffer)
+	cmd.Stdout = Log
+	cmd.Stderr = stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	gz := gzip.NewWriter(stdin)
+	// unlike archives, the files are meant to be used as they are
+	return &sshSink{&tarSink{stdin, gz, tar.NewWriter(gz), time.Now()}, cmd, stderr}, nil
+}
+
+func (s *sshSink) Close() error {
+	err := s.tarSink.Close()
+	if werr := s.cmd.Wait(); werr != nil {
+		if msg := strings.TrimSpace(s.stderr.String()); msg != "" {
+			return fmt.Errorf("ssh: %s", msg)
+		}
+		return fmt.Errorf("ssh: %s", werr)
+	}
+	return err
+}
+
+// Writes the output to a directory on a remote host over SFTP, for hosts which
+// offer no shell. The output is staged locally, and uploaded when the sink is
+// closed, by the system's sftp client in batch mode.
+type sftpSink struct {
+	dirSink
+	target  RemoteTarget
+	dir     string          // the remote directory
+	batch   []string        // the sftp commands writing the output, in order
+	created map[string]bool // the directories made by the batch
+}
+
+// Creates a sink writing into the directory dir within the target directory
+// on the remote host, over SFTP. The directory must not exist yet.
+func NewSFTPSink(r RemoteTarget, dir string) (Sink, error) {
+	staging, err := temps.Dir("skel-sftp")
+	if err != nil {
+		return nil, err
+	}
+	return &sftpSink{dirSink{staging}, r, path.Join(r.Path, dir), nil, make(map[string]bool)}, nil
+}
+
+// Quotes s as a single argument of an sftp batch command.
+func sftpQuote(s string) (string, error) {
+	if strings.ContainsAny(s, "\r\n") {
+		return "", fmt.Errorf("'%s' cannot be written over SFTP, as it contains a line break", s)
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`, nil
+}
+
+// Adds the command to the batch, with the quoted arguments.
+func (s *sftpSink) command(name string, args ...string) error {
+	line := name
+	for _, arg := range args {
+		quoted, err := sftpQuote(arg)
+		if err != nil {
+			return err
+		}
+		line += " " + quoted
+	}
+	s.batch = append(s.batch, line)
+	return nil
+}
+
+// Adds the commands making the directory at the slash separated path, within
+// the output, and its parents.
+func (s *sftpSink) mkdirs(rel string, perm os.FileMode) error {
+	if rel == "." || s.created[rel] {
+		return nil
+	}
+	if err := s.mkdirs(path.Dir(rel), 0755); err != nil {
+		return err
+	}
+	s.created[rel] = true
+	remote := path.Join(s.dir, rel)
+	if err := s.command("mkdir", remote); err != nil {
+		return err
+	}
+	return s.command("chmod", fmt.Sprintf("%o", perm.Perm()), remote)
+}
+
+func (s *sftpSink) MkdirAll(p string, perm os.FileMode) error {
+	if err := s.dirSink.MkdirAll(p, perm); err != nil {
+		return err
+	}
+	return s.mkdirs(filepath.ToSlash(p), perm)
+}
+
+func (s *sftpSink) WriteFile(p string, r io.Reader, perm os.FileMode) error {
+	rel := filepath.ToSlash(p)
+	if err := s.mkdirs(path.Dir(rel), 0755); err != nil {
+		return err
+	}
+	if err := s.dirSink.WriteFile(p, r, perm); err != nil {
+		return err
+	}
+	remote := path.Join(s.dir, rel)
+	if err := s.command("put", filepath.Join(s.root, p), remote); err != nil {
+		return err
+	}
+	return s.command("chmod", fmt.Sprintf("%o", perm.Perm()), remote)
+}
+
+func (s *sftpSink) Symlink(p, target string) error {
+	rel := filepath.ToSlash(p)
+	if err := s.mkdirs(path.Dir(rel), 0755); err != nil {
+		return err
+	}
+	return s.command("ln -s", target, path.Join(s.dir, rel))
+}
+
+// Uploads the output: the parents of the target directory are made when they
+// do not exist, the output directory itself must not exist yet.
+func (s *sftpSink) Close() error {
+	defer temps.Remove(s.root)
+
+	var batch []string
+	p := ""
+	if path.IsAbs(s.target.Path) {
+		p = "/"
+	}
+	for _, elem := range strings.Split(s.target.Path, "/") {
+		if elem == "" || elem == "." {
+			continue
+		}
+		p = path.Join(p, elem)
+		quoted, err := sftpQuote(p)
+		if err != nil {
+			return err
+		}
+		// a failing command is ignored when prefixed with -
+		batch = append(batch, "-mkdir "+quoted)
+	}
+	quoted, err := sftpQuote(s.dir)
+	if err != nil {
+		return err
+	}
+	batch = append(batch, "mkdir "+quoted)
+	batch = append(batch, s.batch...)
+
+	var args []string
+	if s.target.Port != "" {
+		args = append(args, "-P", s.target.Port)
+	}
+	host := s.target.Host
+	if s.target.User != "" {
+		host = s.target.User + "@" + host
+	}
+	args = append(args, "-b", "-", "--", host)
+
+	cmd := exec.Command("sftp", args...)
+	stderr := new(bytes.Buffer)
+	cmd.Stdin = strings.NewReader(strings.Join(batch, "\n") + "\n")
+	cmd.Stderr = stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("sftp: %s", msg)
+		}
+		return fmt.Errorf("sftp: %s", err)
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package skel
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// The errors of a run which went on after the first one, so all of them are
+// reported at once.
+type Errors []error
+
+func (errs Errors) Error() string {
+	if len(errs) == 1 {
+		return errs[0].Error()
+	}
+	msgs := make([]string, len(errs))
+	for i, err := range errs {
+		msgs[i] = "\n  " + err.Error()
+	}
+	return fmt.Sprintf("%d errors:%s", len(errs), strings.Join(msgs, ""))
+}
+
+// Returns the messages of the error, one for each error of Errors.
+func errorMessages(err error) []string {
+	if errs, ok := err.(Errors); ok {
+		var msgs []string
+		for _, e := range errs {
+			msgs = append(msgs, e.Error())
+		}
+		return msgs
+	}
+	return []string{err.Error()}
+}
+
+// Records the changes made while moving the output into place, so a failed
+// run can be rolled back: the paths which were created, and backups of the
+// files which were replaced. Files are only backed up when the run may be
+// rolled back.
+type journal struct {
+	created  []string          // paths which did not exist before, in the order they were created
+	replaced map[string]string // paths which existed before, to their backup
+	backups  string            // directory holding the backups, once there are any
+	rollback bool              // whether replaced files are backed up
+}
+
+func newJournal(rollback bool) *journal {
+	return &journal{replaced: make(map[string]string), rollback: rollback}
+}
+
+// Records that the file at path is about to be written. A path which did not
+// exist is recorded as created; an existing file is moved to a backup, when
+// the run may be rolled back.
+func (j *journal) place(path string) error {
+	info, err := os.Lstat(path)
+	if os.IsNotExist(err) {
+		j.created = append(j.created, path)
+		return nil
+	}
+	// a directory is never replaced by a file, backed up or not
+	if !j.rollback || err != nil || info.IsDir() {
+		return nil
+	}
+	if _, ok := j.replaced[path]; ok {
+		return nil
+	}
+	if j.backups == "" {
+		dir, err := temps.Dir("skel-backup")
+		if err != nil {
+			return err
+		}
+		j.backups = dir
+	}
+	backup := filepath.Join(j.backups, strconv.Itoa(len(j.replaced)))
+	if err := moveFile(path, backup); err != nil {
+		return fmt.Errorf("unable to back up '%s': %s", path, err)
+	}
+	j.replaced[path] = backup
+	return nil
+}
+
+// Creates the directory with its parents, like os.MkdirAll, recording the
+// ones which did not exist.
+func (j *journal) mkdirAll(path string, perm os.FileMode) error {
+	var missing []string
+	for p := filepath.Clean(path); ; p = filepath.Dir(p) {
+		if _, err := os.Lstat(p); err == nil || filepath.Dir(p) == p {
+			break
+		}
+		missing = append(missing, p)
+	}
+	for i := len(missing) - 1; i >= 0; i-- {
+		j.created = append(j.created, missing[i])
+	}
+	return os.MkdirAll(path, perm)
+}
+
+// Records the entries as created within root, which is about to be created
+// with them.
+func (j *journal) moved(root string, entries []Entry) {
+	j.created = append(j.created, root)
+	for _, e := range entries {
+		j.created = append(j.created, filepath.Join(root, e.Path))
+	}
+}
+
+// Undoes the recorded changes: the created paths are removed, most recent
+// first, and the replaced files are restored from their backups. Returns the
+// errors, if any; it goes on after one, so as much as possible is undone.
+func (j *journal) undo() error {
+	var errs Errors
+	for i := len(j.created) - 1; i >= 0; i-- {
+		debugf("Rolling back:   %s\n", j.created[i])
+		if err := os.RemoveAll(j.created[i]); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for path, backup := range j.replaced {
+		debugf("Restoring:      %s\n", path)
+		os.RemoveAll(path)
+		if err := moveFile(backup, path); err != nil {
+			errs = append(errs, fmt.Errorf("unable to restore '%s': %s", path, err))
+		}
+	}
+	j.created, j.replaced = nil, make(map[string]string)
+	if errs != nil {
+		return errs
+	}
+	return nil
+}
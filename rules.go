@@ -0,0 +1,76 @@
+package skel
+
+import (
+	"encoding/xml"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Declares that paths of the skeleton are only generated depending on the
+// values given, like <include glob="docker/**" if="docker"/> or
+// <exclude glob="**/*_test.go" if="!tests"/>.
+type PathRule struct {
+	XMLName xml.Name
+	Glob    string `xml:"glob,attr"`
+	If      string `xml:"if,attr"` // expression deciding whether the rule applies, always when empty
+}
+
+// The <rules> of a skeleton, in the order in which they are declared.
+type PathRules struct {
+	Rules []PathRule `xml:",any"`
+}
+
+// Checks the rules as declared in the skeleton configuration.
+func (r *PathRules) check() error {
+	if r == nil {
+		return nil
+	}
+	for _, rule := range r.Rules {
+		switch rule.XMLName.Local {
+		case "include", "exclude":
+		default:
+			return fmt.Errorf("unknown rule <%s>, expected <include> or <exclude>", rule.XMLName.Local)
+		}
+		if strings.TrimSpace(rule.Glob) == "" {
+			return fmt.Errorf("<%s> has no glob attribute", rule.XMLName.Local)
+		}
+		if rule.If != "" {
+			if _, err := parseExpr(rule.If); err != nil {
+				return fmt.Errorf("<%s glob=\"%s\">: invalid condition '%s': %s", rule.XMLName.Local, rule.Glob, rule.If, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Returns the rules of the skeleton, if any.
+func (t Skeleton) pathRules() []PathRule {
+	if t.Config.Rules == nil {
+		return nil
+	}
+	return t.Config.Rules.Rules
+}
+
+// Reports whether the skeleton path rel, which is relative to the skeleton, is
+// generated with the variables in scope. The first rule matching the path
+// decides: an include rule generates it only when its condition is true, an
+// exclude rule only when it is false. Paths matching no rule are generated.
+func (t Skeleton) included(rel string, scope map[string]string) (bool, error) {
+	slashed := filepath.ToSlash(rel)
+	for _, rule := range t.pathRules() {
+		if !matchGlob(rule.Glob, slashed) {
+			continue
+		}
+		applies := true
+		if rule.If != "" {
+			value, err := Evaluate(rule.If, scope)
+			if err != nil {
+				return false, fmt.Errorf("unable to evaluate the condition '%s' of <%s glob=\"%s\">: %s", rule.If, rule.XMLName.Local, rule.Glob, err)
+			}
+			applies = isTruthy(value)
+		}
+		return applies == (rule.XMLName.Local == "include"), nil
+	}
+	return true, nil
+}
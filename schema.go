@@ -0,0 +1,120 @@
+package skel
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Version of JSON Schema in which the parameters are described.
+const SCHEMA_DIALECT = "https://json-schema.org/draft/2020-12/schema"
+
+// A JSON Schema, limited to what is needed to describe the parameters of a
+// skeleton. Keywords starting with "x-skel-" are specific to skel, and ignored
+// by validators.
+type JSONSchema struct {
+	Schema               string                 `json:"$schema,omitempty"`
+	Title                string                 `json:"title,omitempty"`
+	Description          string                 `json:"description,omitempty"`
+	Type                 string                 `json:"type"`
+	Enum                 []string               `json:"enum,omitempty"`
+	Default              string                 `json:"default,omitempty"`
+	Pattern              string                 `json:"pattern,omitempty"`
+	MinLength            int                    `json:"minLength,omitempty"`
+	Properties           map[string]*JSONSchema `json:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	AdditionalProperties *bool                  `json:"additionalProperties,omitempty"`
+
+	List      bool     `json:"x-skel-list,omitempty"`      // a comma separated list
+	Dynamic   bool     `json:"x-skel-dynamic,omitempty"`   // choices are listed by a command at prompt time
+	Normalize []string `json:"x-skel-normalize,omitempty"` // normalizations applied to the input
+	ParamType string   `json:"x-skel-type,omitempty"`      // the type of the parameter, if not a string
+	Order     []string `json:"x-skel-order,omitempty"`     // the order in which parameters are asked
+}
+
+// Returns the JSON Schema of the answers to the parameters of the skeleton,
+// as recorded in .skel.lock.
+func (t Skeleton) Schema() *JSONSchema {
+	additional := false
+	schema := &JSONSchema{
+		Schema:               SCHEMA_DIALECT,
+		Title:                t.Config.Name,
+		Description:          t.Config.Description,
+		Type:                 "object",
+		Properties:           make(map[string]*JSONSchema),
+		Required:             []string{},
+		AdditionalProperties: &additional,
+	}
+
+	for _, p := range t.Config.Parameters {
+		steps, _ := normalizeSteps(p.Normalize)
+		property := &JSONSchema{
+			Description: p.Description,
+			Type:        "string",
+			Default:     p.Default,
+			List:        p.List,
+			Normalize:   steps,
+		}
+		// like yes for a bool, defaults are recorded as their canonical form
+		if value, err := p.Check(p.Default); err == nil {
+			property.Default = value
+		}
+		if p.Type != PARAM_STRING {
+			property.ParamType = p.Type
+		}
+		// answers are always recorded as strings, so types are patterns
+		switch {
+		case p.List:
+			// checked element by element, below
+		case p.Type == PARAM_BOOL:
+			property.Enum = []string{"true", "false"}
+		case p.Type == PARAM_INT:
+			property.Pattern = `^-?[0-9]+$`
+		case p.Regex != "":
+			property.Pattern = "^(?:" + p.Regex + ")$"
+		}
+		if p.Required && property.Enum == nil {
+			property.MinLength = 1
+		}
+		// the output of commands is only known at prompt time, and running
+		// them here could have side effects
+		if c := p.Choices; c != nil && c.Command != "" {
+			property.Dynamic = true
+		} else if c != nil {
+			property.Enum, _ = t.ChoiceOptions(*c)
+		}
+		if p.List {
+			property.Pattern = listPattern(p, property.Enum)
+			property.Enum = nil
+		}
+		schema.Properties[p.Name] = property
+		// an empty answer is the default, so only parameters without one
+		// need an answer
+		if p.Default == "" {
+			schema.Required = append(schema.Required, p.Name)
+		}
+		schema.Order = append(schema.Order, p.Name)
+	}
+	return schema
+}
+
+// Returns the pattern of the recorded value of the list parameter, of which
+// the elements are joined by commas, or an empty string if any value will do.
+// Every element must be one of the options, if given.
+func listPattern(p SkeletonParams, options []string) string {
+	var element string
+	switch {
+	case options != nil:
+		quoted := make([]string, len(options))
+		for i, o := range options {
+			quoted[i] = regexp.QuoteMeta(o)
+		}
+		element = strings.Join(quoted, "|")
+	case p.Type == PARAM_INT:
+		element = `-?[0-9]+`
+	case p.Regex != "":
+		element = p.Regex
+	default:
+		return ""
+	}
+	return "^(?:(?:" + element + ")(?:, *(?:" + element + "))*)?$"
+}
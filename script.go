@@ -0,0 +1,85 @@
+package skel
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// A single statement of a skeleton script: name = expression.
+type scriptStatement struct {
+	line int
+	name string
+	expr string
+}
+
+// Matches an assignment; the name may contain dots, like "go.package".
+var assignment = regexp.MustCompile(`^\s*([\pL_][\pL\pN_.]*)\s*=([^=].*)$`)
+
+// Parses the <script> of a skeleton: one assignment per line, of which the
+// expression is in the expression language of placeholders. Empty lines and
+// lines starting with # are ignored.
+func parseScript(src string, params []SkeletonParams) ([]scriptStatement, error) {
+	return parseStatements(src, params, false)
+}
+
+// Parses a hook script: like a <script>, with lines which are an expression
+// of their own as well, like mkdir("bin"), evaluated for what the functions
+// they call do. Their statements have no name.
+func parseHookScript(src string, params []SkeletonParams) ([]scriptStatement, error) {
+	return parseStatements(src, params, true)
+}
+
+// Parses the lines of a script, which are assignments or, when calls is set,
+// expressions.
+func parseStatements(src string, params []SkeletonParams, calls bool) ([]scriptStatement, error) {
+	var statements []scriptStatement
+	for i, line := range strings.Split(src, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		m := assignment.FindStringSubmatch(line)
+		if m == nil && calls {
+			if _, err := parseExpr(trimmed); err != nil {
+				return nil, fmt.Errorf("line %d: %s", i+1, err)
+			}
+			statements = append(statements, scriptStatement{i + 1, "", trimmed})
+			continue
+		}
+		if m == nil {
+			return nil, fmt.Errorf("line %d: expected name = expression", i+1)
+		}
+		name, expr := m[1], strings.TrimSpace(m[2])
+		if isBuiltinName(name) {
+			return nil, fmt.Errorf("line %d: '%s' is a built-in variable", i+1, name)
+		}
+		for _, p := range params {
+			if p.Name == name {
+				return nil, fmt.Errorf("line %d: '%s' is a parameter", i+1, name)
+			}
+		}
+		if _, err := parseExpr(expr); err != nil {
+			return nil, fmt.Errorf("line %d: %s", i+1, err)
+		}
+		statements = append(statements, scriptStatement{i + 1, name, expr})
+	}
+	return statements, nil
+}
+
+// Runs the script of the skeleton, adding the variables it assigns to vars.
+// Statements are run in order, so later statements can use the variables of
+// earlier ones. Variables of which the expression cannot be evaluated are
+// left undefined, and placeholders using them are left unsubstituted.
+func (t Skeleton) runScript(vars map[string]string) {
+	statements, _ := parseScript(t.Config.Script, t.Config.Parameters)
+	for _, s := range statements {
+		value, err := Evaluate(s.expr, vars)
+		if err != nil {
+			debugf("Script line %d: %s\n", s.line, err)
+			continue
+		}
+		vars[s.name] = value
+	}
+}
@@ -0,0 +1,229 @@
+package skel
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Destination of generated output. Paths are relative to the output
+// directory.
+type Sink interface {
+	// Creates a directory, and any missing parents.
+	MkdirAll(path string, perm os.FileMode) error
+	// Creates a file with the contents read from r.
+	WriteFile(path string, r io.Reader, perm os.FileMode) error
+	// Creates a symbolic link to target.
+	Symlink(path, target string) error
+	// Finishes writing the output.
+	Close() error
+}
+
+// Writes the output into a directory.
+type dirSink struct {
+	root string
+}
+
+func (s dirSink) MkdirAll(path string, perm os.FileMode) error {
+	return os.MkdirAll(filepath.Join(s.root, path), perm)
+}
+
+func (s dirSink) WriteFile(path string, r io.Reader, perm os.FileMode) error {
+	f, err := os.OpenFile(filepath.Join(s.root, path), os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func (s dirSink) Symlink(path, target string) error {
+	return os.Symlink(target, filepath.Join(s.root, path))
+}
+
+func (s dirSink) Close() error {
+	return nil
+}
+
+// Discards all output, for dry runs.
+type discardSink struct{}
+
+func (discardSink) MkdirAll(string, os.FileMode) error             { return nil }
+func (discardSink) WriteFile(string, io.Reader, os.FileMode) error { return nil }
+func (discardSink) Symlink(string, string) error                   { return nil }
+func (discardSink) Close() error                                   { return nil }
+
+// Returns the kind of archive ("zip" or "tar.gz") for the given output path,
+// or an empty string when the output is not an archive.
+func archiveKind(path string) string {
+	lower := strings.ToLower(path)
+	switch {
+	case strings.HasSuffix(lower, ".zip"):
+		return "zip"
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		return "tar.gz"
+	}
+	return ""
+}
+
+// Returns the modification time of all archive entries, so repeated runs
+// result in identical archives. SOURCE_DATE_EPOCH is honored, see
+// https://reproducible-builds.org/specs/source-date-epoch/. Otherwise the
+// earliest time a zip file can represent is used.
+func archiveTime() time.Time {
+	if epoch, err := strconv.ParseInt(os.Getenv("SOURCE_DATE_EPOCH"), 10, 64); err == nil {
+		return time.Unix(epoch, 0).UTC()
+	}
+	return time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC)
+}
+
+// Returns the normalized permissions of an archive entry: 0755 for
+// directories and executables, 0644 for anything else.
+func archivePerm(perm os.FileMode, dir bool) os.FileMode {
+	if dir || perm&0111 != 0 {
+		return 0755
+	}
+	return 0644
+}
+
+// Creates a sink writing a reproducible archive of the given kind to the file
+// at path: entries are written in the order given, with normalized
+// timestamps, permissions and ownership.
+func NewArchiveSink(kind, path string) (Sink, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	if kind == "zip" {
+		return &zipSink{f, zip.NewWriter(f), archiveTime()}, nil
+	}
+	gz := gzip.NewWriter(f)
+	return &tarSink{f, gz, tar.NewWriter(gz), archiveTime()}, nil
+}
+
+// Writes the output into a zip file.
+type zipSink struct {
+	f     *os.File
+	w     *zip.Writer
+	mtime time.Time
+}
+
+func (s *zipSink) MkdirAll(path string, perm os.FileMode) error {
+	hdr := &zip.FileHeader{Name: filepath.ToSlash(path) + "/", Modified: s.mtime}
+	hdr.SetMode(os.ModeDir | archivePerm(perm, true))
+	_, err := s.w.CreateHeader(hdr)
+	return err
+}
+
+func (s *zipSink) WriteFile(path string, r io.Reader, perm os.FileMode) error {
+	hdr := &zip.FileHeader{Name: filepath.ToSlash(path), Method: zip.Deflate, Modified: s.mtime}
+	hdr.SetMode(archivePerm(perm, false))
+	w, err := s.w.CreateHeader(hdr)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, r)
+	return err
+}
+
+func (s *zipSink) Symlink(path, target string) error {
+	hdr := &zip.FileHeader{Name: filepath.ToSlash(path), Method: zip.Store, Modified: s.mtime}
+	hdr.SetMode(os.ModeSymlink | 0777)
+	w, err := s.w.CreateHeader(hdr)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, target)
+	return err
+}
+
+func (s *zipSink) Close() error {
+	if err := s.w.Close(); err != nil {
+		s.f.Close()
+		return err
+	}
+	return s.f.Close()
+}
+
+// Writes the output into a gzipped tar file.
+type tarSink struct {
+	f     io.WriteCloser
+	gz    *gzip.Writer
+	w     *tar.Writer
+	mtime time.Time
+}
+
+func (s *tarSink) MkdirAll(path string, perm os.FileMode) error {
+	return s.w.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeDir,
+		Name:     filepath.ToSlash(path) + "/",
+		Mode:     int64(archivePerm(perm, true)),
+		ModTime:  s.mtime,
+		Format:   tar.FormatPAX,
+	})
+}
+
+func (s *tarSink) WriteFile(path string, r io.Reader, perm os.FileMode) error {
+	// the size must be known up front; files are streamed, anything else
+	// is buffered
+	var size int64
+	if f, ok := r.(*os.File); ok {
+		info, err := f.Stat()
+		if err != nil {
+			return err
+		}
+		size = info.Size()
+	} else {
+		var buf bytes.Buffer
+		if _, err := io.Copy(&buf, r); err != nil {
+			return err
+		}
+		size, r = int64(buf.Len()), &buf
+	}
+	err := s.w.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     filepath.ToSlash(path),
+		Mode:     int64(archivePerm(perm, false)),
+		Size:     size,
+		ModTime:  s.mtime,
+		Format:   tar.FormatPAX,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(s.w, r)
+	return err
+}
+
+func (s *tarSink) Symlink(path, target string) error {
+	return s.w.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeSymlink,
+		Name:     filepath.ToSlash(path),
+		Linkname: target,
+		Mode:     0777,
+		ModTime:  s.mtime,
+		Format:   tar.FormatPAX,
+	})
+}
+
+func (s *tarSink) Close() error {
+	err := s.w.Close()
+	if err == nil {
+		err = s.gz.Close()
+	}
+	if err != nil {
+		s.f.Close()
+		return err
+	}
+	return s.f.Close()
+}
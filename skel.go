@@ -0,0 +1,705 @@
+package skel
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Skeleton configuration XML file
+type SkeletonConfig struct {
+	Format       string             `xml:"format,attr"`
+	Name         string             `xml:"name"`
+	Version      string             `xml:"version"`
+	Description  string             `xml:"description"`
+	Parameters   []SkeletonParams   `xml:"parameters>param"`
+	Gitignore    string             `xml:"gitignore"` // bundled .gitignore templates to emit, comma separated
+	Outputs      []SkeletonOutput   `xml:"outputs>output"`
+	Substitution []SubstitutionRule `xml:"substitution>include"` // paths which are substituted, all when empty
+	OutDirName   string             `xml:"outdir"`               // name of the output directory, substituted
+	Script       string             `xml:"script"`               // assignments of computed variables
+	Locales      *SkeletonLocales   `xml:"locales"`              // locales of file variants, if any
+	Engine       string             `xml:"engine"`               // how names and contents are rendered, ENGINE_SUBSTITUTION by default
+	Rules        *PathRules         `xml:"rules"`                // paths which are only generated depending on the values given
+	Hooks        SkeletonHooks      `xml:"hooks"`                // commands run before and after generating
+	Raw          string             `xml:"raw"`                  // globs of files copied byte-for-byte, comma separated
+}
+
+type SkeletonParams struct {
+	Name        string        `xml:"name,attr"`
+	Description string        `xml:"description,attr"`
+	Normalize   string        `xml:"normalize,attr"` // normalizations of the input, comma separated
+	List        bool          `xml:"list,attr"`      // whether the value is a comma separated list, fanning out paths using it
+	Choices     *ParamChoices `xml:"choices"`        // the options to choose from, if limited
+	Type        string        `xml:"type,attr"`      // PARAM_STRING (the default), PARAM_BOOL, PARAM_INT or PARAM_ENUM
+	Default     string        `xml:"default,attr"`   // value of an empty answer, substituted
+	Regex       string        `xml:"regex,attr"`     // regular expression the whole value must match, if any
+	Required    bool          `xml:"required,attr"`  // whether an empty value is refused
+}
+
+func NewSkeleton(location string, config SkeletonConfig) *Skeleton {
+	t := new(Skeleton)
+	t.Location = location
+	t.Config = config
+	t.Unsubstituted = make(map[string]bool)
+	t.rendered = make(map[string]string)
+	t.warnings = new([]string)
+
+	t.outDirBase = fmt.Sprintf("%s-%d", t.Config.Name, time.Now().UnixNano())
+
+	// random values are drawn once, so they are equal throughout the output
+	t.uuid = randomUUID()
+	t.randomhex = randomHex(16)
+	t.date = generationDate()
+	t.user = userName()
+
+	return t
+}
+
+// Basic skeleton structure.
+type Skeleton struct {
+	Location      string            // location of the skeleton
+	Source        string            // the skeleton as given by the user (directory or zip file)
+	Config        SkeletonConfig    // skeleton configuration (parsed from XML)
+	Outdir        string            // Output directory
+	Dryrun        bool              // whether it's a dry run, without output
+	KeyValues     map[string]string // substitutable keys and their values
+	Unsubstituted map[string]bool   // Unsubstituted particles
+
+	outDirBase string              // base output directory, which is the skeleton name + random int
+	remote     *RemoteTarget       // the remote host and directory the output is written to, if any
+	existing   []string            // paths of the output which existed before, when merging
+	hooked     []string            // files of the output which the post-generate hooks created
+	rendered   map[string]string   // rendered paths, mapped to the source path they were rendered from
+	warnings   *[]string           // skeleton files which were skipped while rendering, and why
+	uuid       string              // random UUID for ${skel.uuid}
+	randomhex  string              // random hex string for ${skel.randomhex}
+	date       string              // date of generation for ${__date} and ${__year}
+	user       string              // user generating, for ${__user}
+	quote      func(string) string // quotes every substituted value, when rendering a command, if set
+}
+
+// Records a warning about the skeleton, which does not stop the generation.
+func (t Skeleton) warnf(format string, args ...interface{}) {
+	*t.warnings = append(*t.warnings, fmt.Sprintf(format, args...))
+}
+
+// Returns the warnings recorded while rendering, like skeleton files which were
+// skipped.
+func (t Skeleton) Warnings() []string {
+	return append([]string{}, *t.warnings...)
+}
+
+// Returns the directory in which the output is generated.
+func (t Skeleton) outputDir() string {
+	if t.remote != nil {
+		return t.remote.String() + "/" + t.outDirBase
+	}
+	return filepath.Join(t.Outdir, t.outDirBase)
+}
+
+// Sets the name of the output directory from the given template, which is
+// substituted. The name must be a single, non-empty path element.
+func (t *Skeleton) setOutDirName(template string) error {
+	name, err := t.findReplace(template)
+	if err != nil {
+		return err
+	}
+	if name, err = checkDirName(name); err != nil {
+		return err
+	}
+	t.outDirBase = name
+	return nil
+}
+
+// Checks whether the name is a single, non-empty path element. Returns the
+// name normalized, without surrounding whitespace.
+func checkDirName(name string) (string, error) {
+	name = strings.TrimSpace(normalizeNFC(name))
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return "", fmt.Errorf("'%s' is not a valid directory name", name)
+	}
+	return name, nil
+}
+
+// Returns the built-in ${skel.*} variables, describing the tool and the
+// skeleton which produced the output, and the ${__*} variables describing the
+// run.
+func (t Skeleton) builtinVariables() map[string]string {
+	outdir := t.outputDir()
+	if abs, err := filepath.Abs(outdir); err == nil && t.remote == nil {
+		outdir = abs
+	}
+	year := t.date
+	if len(year) > 4 {
+		year = year[:4]
+	}
+
+	return map[string]string{
+		"skel.version":         VERSION,
+		"skel.skeletonname":    t.Config.Name,
+		"skel.skeletonversion": t.Config.Version,
+		"skel.outdir":          outdir,
+		"skel.uuid":            t.uuid,
+		"skel.randomhex":       t.randomhex,
+		"__date":               t.date,
+		"__year":               year,
+		"__user":               t.user,
+		"__uuid":               t.uuid,
+	}
+}
+
+// Returns all substitutable variables: the values given by the user and their
+// derived variants, merged with the built-in variables and the variables
+// computed by the script. Values given by the user take precedence over
+// derived variants, built-ins take precedence over everything.
+func (t Skeleton) variables() map[string]string {
+	vars := make(map[string]string)
+	for k, v := range t.KeyValues {
+		for dk, dv := range derivedVariants(k, v) {
+			vars[dk] = dv
+		}
+	}
+	for k, v := range t.KeyValues {
+		vars[k] = v
+	}
+	for k, v := range t.builtinVariables() {
+		vars[k] = v
+	}
+	t.runScript(vars)
+	return vars
+}
+
+// Finds occurences in the src string of ${..} vars and will substitute them
+// with any given values in the KeyValues map, or the built-in variables.
+// Loop blocks are expanded as well. A skeleton using Go templates renders src
+// as a template instead, which fails on errors in the template.
+func (t Skeleton) findReplace(src string) (string, error) {
+	return t.findReplaceWith(src, t.variables())
+}
+
+// Like findReplace, but with the variables in the given scope.
+func (t Skeleton) findReplaceWith(src string, scope map[string]string) (string, error) {
+	if t.templated() {
+		if t.quote != nil {
+			quoted := make(map[string]string, len(scope))
+			for k, v := range scope {
+				quoted[k] = t.quote(v)
+			}
+			scope = quoted
+		}
+		return t.renderGoTemplate(src, scope)
+	}
+	var out strings.Builder
+	t.renderNodes(parseTemplate(src), scope, &out)
+	return out.String(), nil
+}
+
+// Returns the scopes in which a skeleton path is rendered. A path using list
+// parameters is rendered once for every element, or for every combination of
+// elements when it uses several, with the parameter set to the element.
+// Other paths are rendered once, with all variables.
+func (t Skeleton) fanOut(path string) []map[string]string {
+	scopes := []map[string]string{t.variables()}
+	if t.templated() {
+		// lists are ranged over within templates instead
+		return scopes
+	}
+
+	used := templateVariables(path)
+	for _, p := range t.Config.Parameters {
+		if !p.List || !usesVariable(used, p.Name) {
+			continue
+		}
+
+		var items []string
+		for _, item := range listItems(t.KeyValues[p.Name]) {
+			if !contains(items, item) {
+				items = append(items, item)
+			}
+		}
+
+		var fanned []map[string]string
+		for _, scope := range scopes {
+			for i, item := range items {
+				child := loopScope(scope, items, i)
+				for k, v := range derivedVariants(p.Name, item) {
+					child[k] = v
+				}
+				child[p.Name] = item
+				fanned = append(fanned, child)
+			}
+		}
+		scopes = fanned
+	}
+	return scopes
+}
+
+// Reports whether the variable names include the given variable, or any of
+// its derived variants.
+func usesVariable(names []string, name string) bool {
+	for _, n := range names {
+		if n == name || strings.HasPrefix(n, name+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// A single directory or file of the generated output.
+type Entry struct {
+	Path    string      // path relative to the output directory, with variables substituted
+	Source  string      // path of the skeleton file or directory it was rendered from
+	Dir     bool        // whether this is a directory
+	Content []byte      // rendered contents of a file
+	Root    string      // destination root given in <outputs>, or empty for the output directory
+	Raw     bool        // whether the file is copied from Source as-is, without reading it into Content
+	Link    string      // target of a symbolic link, which is recreated as-is
+	Mode    os.FileMode // mode of the skeleton file or directory, of which the permissions are kept
+	ModTime time.Time   // modification time of the skeleton file or directory
+
+	sum string // digest of a raw file
+}
+
+// Renders the skeleton in memory: walks the skeleton and returns every
+// directory and file of the output, with names and contents substituted,
+// sorted by path. The output directory itself is not included.
+func (t Skeleton) Render() ([]Entry, error) {
+	var entries []Entry
+	// rendering goes on after an error, so all of them are reported
+	var errs Errors
+
+	err := filepath.Walk(t.Location, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			errs = append(errs, err)
+			return nil
+		}
+
+		x := filepath.Clean(t.Location)
+		y := filepath.Clean(path)
+		// remove the template location path from the walked path, which
+		// leaves the path relative to the skeleton (with a leading separator)
+		newp := strings.Replace(y, x, "", -1)
+		if newp == "" {
+			// the skeleton directory itself
+			return nil
+		}
+		newp, ok := t.localize(newp, info.IsDir())
+		if !ok {
+			// a variant for another locale
+			return nil
+		}
+
+		scopes := t.fanOut(t.substitutedPath(newp, info.IsDir()))
+		fanned := make(map[string]bool)
+		for _, scope := range scopes {
+			entry, err := t.renderEntry(newp, path, info, scope)
+			if err == errSkipped {
+				// a directory can only be skipped entirely when it is not
+				// fanned out over the elements of a list
+				if info.IsDir() && len(scopes) == 1 {
+					return filepath.SkipDir
+				}
+				continue
+			}
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			// elements differing only in their case or punctuation may
+			// result in the same path
+			if !fanned[entry.Path] {
+				fanned[entry.Path] = true
+				entries = append(entries, entry)
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	if errs != nil {
+		return nil, errs
+	}
+
+	// sorted by rendered name, so the order of the output never depends on
+	// the values given; parents still precede their children
+	sort.Slice(entries, func(i, j int) bool {
+		return filepath.ToSlash(entries[i].Path) < filepath.ToSlash(entries[j].Path)
+	})
+
+	if entries, err = t.addGitignore(entries); err != nil {
+		return nil, err
+	}
+	return t.route(entries)
+}
+
+// Returned by renderEntry when an entry is left out of the output.
+var errSkipped = errors.New("skipped")
+
+// Renders a single directory or file of the skeleton at path, which is rel
+// relative to the skeleton, with the variables in scope.
+func (t Skeleton) renderEntry(rel, path string, info os.FileInfo, scope map[string]string) (Entry, error) {
+	if ok, err := t.included(rel, scope); err != nil {
+		return Entry{}, err
+	} else if !ok {
+		debugf("Leaving out:    %s\n", path)
+		return Entry{}, errSkipped
+	}
+
+	newp, err := t.renderPath(rel, info.IsDir(), scope) // substitute with variables
+	if err != nil {
+		return Entry{}, fmt.Errorf("unable to render the name of '%s': %s", path, err)
+	}
+	if newp == "" {
+		// left out by a template
+		return Entry{}, errSkipped
+	}
+
+	// names are normalized, so the output is equal regardless of whether
+	// the values were entered decomposed (like on macOS) or not
+	newp = normalizeNFC(newp)
+	if err := t.claimPath(newp, path); err != nil {
+		t.warnf("Skipping '%s': %s", path, err)
+		return Entry{}, errSkipped
+	}
+
+	// values like "../../etc" must not escape the output directory
+	out := filepath.Join(".", newp)
+	if !withinDir(".", out) {
+		return Entry{}, fmt.Errorf("refusing to create '%s' (from '%s'): it is outside of the output directory", newp, path)
+	}
+
+	entry := Entry{Path: out, Source: path, Dir: info.IsDir(), Mode: info.Mode(), ModTime: info.ModTime()}
+	if entry.Dir {
+		return entry, nil
+	}
+	if info.Mode()&os.ModeSymlink != 0 {
+		if entry.Link, err = os.Readlink(path); err != nil {
+			t.warnf("Skipping '%s': %s", path, err)
+			return Entry{}, errSkipped
+		}
+		return entry, nil
+	}
+
+	// binary and verbatim files are streamed when written, and never
+	// substituted
+	raw, err := t.raw(rel, path)
+	if err == nil && raw {
+		entry.Raw = true
+		entry.sum, err = fileDigest(path)
+	}
+	if err != nil {
+		t.warnf("Skipping '%s': %s", path, err)
+		return Entry{}, errSkipped
+	}
+	if raw {
+		return entry, nil
+	}
+
+	// read original contents, substitute
+	origBytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.warnf("Skipping '%s': %s", path, err)
+		return Entry{}, errSkipped
+	}
+	rendered, err := t.findReplaceWith(string(origBytes), scope)
+	if err != nil {
+		return Entry{}, fmt.Errorf("unable to render '%s': %s", path, err)
+	}
+	entry.Content = []byte(rendered)
+	return entry, nil
+}
+
+// Renders the skeleton and writes the output to the sink. Entries destined for
+// other roots are not written. Returns all rendered entries.
+func (t Skeleton) Walk(sink Sink) ([]Entry, error) {
+	entries, err := t.Render()
+	if err != nil {
+		return nil, err
+	}
+
+	// writing goes on after an error, so all of them are reported
+	var errs Errors
+	for _, e := range entriesIn(entries, "") {
+		if err := t.writeEntry(sink, e); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if errs != nil {
+		return entries, errs
+	}
+
+	return entries, nil
+}
+
+// Writes a single entry of the output.
+func (t Skeleton) writeEntry(sink Sink, e Entry) error {
+	generationLock.Lock()
+	defer generationLock.Unlock()
+
+	// entries are reported with their final location
+	finalpath := filepath.Join(t.outputDir(), e.Path)
+	if e.Root != "" {
+		finalpath = filepath.Join(e.Root, e.Path)
+	} else if t.remote != nil {
+		// a remote location is an URL, which would be mangled by Join
+		finalpath = t.outputDir() + "/" + filepath.ToSlash(e.Path)
+	}
+
+	if e.Dir {
+		// create directory
+		debugf("Creating dir:   %s\n", finalpath)
+		return sink.MkdirAll(e.Path, e.perm())
+	}
+	if e.Link != "" {
+		debugf("Creating link:  %s -> %s\n", finalpath, e.Link)
+		return sink.Symlink(e.Path, e.Link)
+	}
+
+	// create file
+	debugf("Creating file:  %s\n", finalpath)
+	r, err := e.Open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	return sink.WriteFile(e.Path, r, e.perm())
+}
+
+// Parses a single skeleton directory, returns a skeleton or an error
+// when the skeleton dir did not contain a (valid) config.xml file.
+func ParseSkeleton(tdir string) (*Skeleton, error) {
+	pathtoconfig := filepath.Join(tdir, "config.xml")
+	cfg, err := os.Open(pathtoconfig)
+	if err != nil {
+		// config file not found, not a skeleton
+		return nil, fmt.Errorf("Unable to open skeleton 'config.xml': %s", err)
+	}
+
+	defer cfg.Close()
+
+	confData, err := ioutil.ReadAll(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	tmplConfig := SkeletonConfig{}
+	if err := xml.Unmarshal(confData, &tmplConfig); err != nil {
+		return nil, fmt.Errorf("invalid '%s': %s", pathtoconfig, err)
+	}
+	if err := checkFormat(tmplConfig.Format); err != nil {
+		return nil, err
+	}
+	for _, p := range tmplConfig.Parameters {
+		if _, err := normalizeSteps(p.Normalize); err != nil {
+			return nil, fmt.Errorf("parameter '%s': %s", p.Name, err)
+		}
+		if err := p.checkDeclaration(); err != nil {
+			return nil, fmt.Errorf("parameter '%s': %s", p.Name, err)
+		}
+	}
+	if err := checkEngine(tmplConfig.Engine); err != nil {
+		return nil, err
+	}
+	if err := tmplConfig.Rules.check(); err != nil {
+		return nil, fmt.Errorf("invalid <rules>: %s", err)
+	}
+	if _, err := parseScript(tmplConfig.Script, tmplConfig.Parameters); err != nil {
+		return nil, fmt.Errorf("invalid <script>: %s", err)
+	}
+	for _, h := range append(append([]SkeletonHook{}, tmplConfig.Hooks.Pre...), tmplConfig.Hooks.Post...) {
+		if _, err := parseHookScript(h.Text, tmplConfig.Parameters); h.Script && err != nil {
+			return nil, fmt.Errorf("invalid hook script: %s", err)
+		}
+	}
+	if l := tmplConfig.Locales; l != nil && strings.TrimSpace(l.Param) == "" {
+		return nil, fmt.Errorf("<locales> has no param attribute")
+	}
+
+	location := filepath.Dir(cfg.Name())
+
+	skeleton := NewSkeleton(location, tmplConfig)
+
+	return skeleton, nil
+}
+
+// Attempts to unzip the given file to the temp directory. Will return the output
+// directory or an error when anything failed.
+func Unzip(zipfile string) (gendir string, err error) {
+	r, err := zip.OpenReader(zipfile)
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+
+	// create temp dir, which is removed when skel exits
+	targetDir, err := temps.Dir("skel")
+	if err != nil {
+		return "", err
+	}
+
+	debugf("Using temporary directory '%s'\n", targetDir)
+
+	// symbolic links are created last, so no file is extracted through one
+	var links []*zip.File
+	for _, f := range r.File {
+		if f.Mode()&os.ModeSymlink != 0 {
+			links = append(links, f)
+			continue
+		}
+		err := unzipFile(f, targetDir)
+		if err != nil {
+			return targetDir, err
+		}
+	}
+	for _, f := range links {
+		if err := unzipLink(f, targetDir); err != nil {
+			return targetDir, err
+		}
+	}
+
+	return targetDir, nil
+}
+
+// Extracts a single file or directory from a zip into targetDir.
+func unzipFile(f *zip.File, targetDir string) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	// the file or directory to be created
+	creationTarget := filepath.Join(targetDir, f.Name)
+	if !withinDir(targetDir, creationTarget) {
+		return fmt.Errorf("'%s' is outside of the zip file's directory", f.Name)
+	}
+	if err := checkParents(targetDir, creationTarget); err != nil {
+		return err
+	}
+
+	// create file in created directory
+	if f.FileInfo().IsDir() {
+		debugf("Creating directory '%s'\n", f.Name)
+		return os.MkdirAll(creationTarget, Entry{Dir: true, Mode: f.Mode()}.perm())
+	}
+
+	// it's a file, create it, keeping its permissions and modification time
+	newfile, err := os.OpenFile(creationTarget, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, Entry{Mode: f.Mode()}.perm())
+	if err != nil {
+		return err
+	}
+	defer newfile.Close()
+
+	debugf("Unzipping file '%s'\n", f.Name)
+	if _, err = io.Copy(newfile, rc); err != nil {
+		return err
+	}
+	if err := newfile.Close(); err != nil {
+		return err
+	}
+	return os.Chtimes(creationTarget, f.Modified, f.Modified)
+}
+
+// Creates a symbolic link from a zip in targetDir. The target of the link is
+// the contents of the zip entry.
+func unzipLink(f *zip.File, targetDir string) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	creationTarget := filepath.Join(targetDir, f.Name)
+	if !withinDir(targetDir, creationTarget) {
+		return fmt.Errorf("'%s' is outside of the zip file's directory", f.Name)
+	}
+	if err := checkParents(targetDir, creationTarget); err != nil {
+		return err
+	}
+	target, err := ioutil.ReadAll(rc)
+	if err != nil {
+		return err
+	}
+
+	debugf("Creating link '%s'\n", f.Name)
+	if err := os.MkdirAll(filepath.Dir(creationTarget), 0755); err != nil {
+		return err
+	}
+	return os.Symlink(string(target), creationTarget)
+}
+
+// Opens the skeleton at the given location, which is either a directory, a
+// zip or tar.gz file, a bundled skeleton like "gallery:go-cli", an installed
+// skeleton like "installed:svc", a git repository or an archive at a URL. An
+// archive or bundled skeleton is extracted to a temporary directory first, a
+// remote skeleton is cloned or downloaded there.
+func Load(in string) (*Skeleton, error) {
+	var targetFileDir string = in
+
+	if strings.HasPrefix(in, ALIAS_PREFIX) {
+		name := strings.TrimPrefix(in, ALIAS_PREFIX)
+		a, ok := LoadUserConfig().Alias(name)
+		if !ok {
+			return nil, fmt.Errorf("Unknown alias '%s'", name)
+		}
+		dir, err := ResolveAlias(a)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to fetch '%s': %s", a, err)
+		}
+		t, err := Load(dir)
+		if err != nil {
+			return nil, err
+		}
+		// record what the alias resolved to, not where it is cached
+		t.Source = a.String()
+		return t, nil
+	} else if strings.HasPrefix(in, INSTALLED_PREFIX) {
+		tdir, err := installedDir(strings.TrimPrefix(in, INSTALLED_PREFIX))
+		if err != nil {
+			return nil, fmt.Errorf("Unable to open installed skeleton: %s", err)
+		}
+		targetFileDir = tdir
+	} else if strings.HasPrefix(in, GALLERY_PREFIX) {
+		tdir, err := ExtractGallery(strings.TrimPrefix(in, GALLERY_PREFIX))
+		if err != nil {
+			return nil, fmt.Errorf("Unable to open bundled skeleton: %s", err)
+		}
+		targetFileDir = tdir
+	} else if _, err := os.Stat(in); err != nil && isRemote(in) {
+		tdir, err := fetchSkeleton(in)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to fetch '%s': %s", in, err)
+		}
+		targetFileDir = tdir
+	} else if stat, err := os.Stat(in); err != nil {
+		// determine type of input (directory, zip or tar.gz file)
+		return nil, fmt.Errorf("Unable to open input directory or file '%s': %s", in, err)
+	} else if !stat.IsDir() {
+		tdir, err := unpackArchive(in)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to open '%s': %s", in, err)
+		}
+
+		targetFileDir = tdir
+	}
+
+	t, err := ParseSkeleton(targetFileDir)
+	if err != nil {
+		return nil, fmt.Errorf("Error opening skeleton: %s", err)
+	}
+	t.Source = in
+
+	return t, nil
+}
@@ -0,0 +1,166 @@
+package skel
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// Reports whether path is dir itself or lies within dir. Both paths are
+// cleaned first, so "dir/a/../../b" is not within dir.
+func withinDir(dir, path string) bool {
+	rel, err := filepath.Rel(filepath.Clean(dir), filepath.Clean(path))
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) && !filepath.IsAbs(rel)
+}
+
+// Returns an error when writing path, which is within dir, would write outside
+// of dir through a link among its parents, like d/passwd where d links to /etc.
+// The deepest parent which exists is resolved.
+func checkParents(dir, path string) error {
+	root, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		// nothing within a directory which does not exist is a link
+		return nil
+	}
+	parent := filepath.Dir(filepath.Clean(path))
+	for {
+		if _, err := os.Lstat(parent); err == nil {
+			break
+		}
+		if !withinDir(dir, parent) || parent == filepath.Clean(dir) {
+			return nil
+		}
+		parent = filepath.Dir(parent)
+	}
+	resolved, err := filepath.EvalSymlinks(parent)
+	if err != nil {
+		return err
+	}
+	if !withinDir(root, resolved) {
+		return fmt.Errorf("'%s' is outside of '%s', through a link", path, dir)
+	}
+	return nil
+}
+
+// Moves the directory src to dst, which must not exist yet. The directory is
+// renamed when possible. When src and dst are on different filesystems,
+// renaming is impossible and the tree is copied and removed instead.
+//
+// The move holds the generation lock, so an interrupt waits for it to
+// complete rather than leaving a partially copied tree behind.
+func moveTree(src, dst string) error {
+	generationLock.Lock()
+	defer generationLock.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+
+	// temporary directories are only accessible by the owner
+	if err := os.Chmod(src, 0755); err != nil {
+		return err
+	}
+
+	err := os.Rename(src, dst)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, syscall.EXDEV) {
+		return err
+	}
+
+	debugf("Copying '%s' to '%s' (different filesystems)\n", src, dst)
+	if err := copyTree(src, dst); err != nil {
+		os.RemoveAll(dst)
+		return err
+	}
+	return os.RemoveAll(src)
+}
+
+// Moves the file src to dst, like moveTree does for directories.
+func moveFile(src, dst string) error {
+	generationLock.Lock()
+	defer generationLock.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+
+	err := os.Rename(src, dst)
+	if err == nil || !errors.Is(err, syscall.EXDEV) {
+		return err
+	}
+
+	info, err := os.Lstat(src)
+	if err != nil {
+		return err
+	}
+	if info.Mode()&os.ModeSymlink != 0 {
+		link, err := os.Readlink(src)
+		if err != nil {
+			return err
+		}
+		err = os.Symlink(link, dst)
+	} else {
+		err = copyFile(src, dst, info.Mode().Perm())
+	}
+	if err != nil {
+		os.Remove(dst)
+		return err
+	}
+	return os.Remove(src)
+}
+
+// Recursively copies the directory src to dst, preserving permissions and
+// symbolic links.
+func copyTree(src, dst string) error {
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		switch {
+		case info.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm())
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		default:
+			return copyFile(path, target, info.Mode().Perm())
+		}
+	})
+}
+
+// Copies the file src to dst, creating dst with the given permissions.
+func copyFile(src, dst string, perm os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
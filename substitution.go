@@ -0,0 +1,95 @@
+package skel
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// Declares which paths of the skeleton are substituted, like
+// <include glob="**/*.go"/>. When a skeleton declares any of these, files
+// matching none of them are copied verbatim.
+type SubstitutionRule struct {
+	Glob    string `xml:"glob,attr"`
+	Content string `xml:"content,attr"` // "false" to copy the contents verbatim
+	Names   string `xml:"names,attr"`   // "false" to keep the name verbatim
+}
+
+// Reports whether the name and the contents of the skeleton path rel, which is
+// relative to the skeleton, are substituted. The first matching rule decides.
+// Directories matching no rule still have their names substituted.
+func (t Skeleton) substitutes(rel string, dir bool) (names, content bool) {
+	if len(t.Config.Substitution) == 0 {
+		return true, true
+	}
+	slashed := filepath.ToSlash(rel)
+	for _, r := range t.Config.Substitution {
+		if matchGlob(r.Glob, slashed) {
+			return r.Names != "false", r.Content != "false"
+		}
+	}
+	return dir, false
+}
+
+// Returns the elements of the skeleton path rel, each paired with whether its
+// name is substituted. The path is that of a directory when dir is true.
+func (t Skeleton) pathElements(rel string, dir bool) (elements []string, names []bool) {
+	prefix := ""
+	all := strings.Split(strings.Trim(filepath.ToSlash(rel), "/"), "/")
+	for i, element := range all {
+		prefix += "/" + element
+		substituted, _ := t.substitutes(prefix, dir || i < len(all)-1)
+		elements = append(elements, element)
+		names = append(names, substituted)
+	}
+	return elements, names
+}
+
+// Returns the part of the skeleton path rel of which the names are
+// substituted, for finding the variables it uses.
+func (t Skeleton) substitutedPath(rel string, dir bool) string {
+	if len(t.Config.Substitution) == 0 {
+		return rel
+	}
+	var parts []string
+	elements, names := t.pathElements(rel, dir)
+	for i, element := range elements {
+		if names[i] {
+			parts = append(parts, element)
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+// Renders the skeleton path rel with the variables in scope. When the
+// skeleton declares substitution rules, every element of the path is
+// substituted according to the rule matching it, and is otherwise kept as-is.
+// Returns an empty path when the name of a skeleton using Go templates renders
+// empty, like {{if .docker}}Dockerfile{{end}}, which leaves it out.
+func (t Skeleton) renderPath(rel string, dir bool, scope map[string]string) (string, error) {
+	if len(t.Config.Substitution) == 0 {
+		rendered, err := t.findReplaceWith(rel, scope)
+		if err != nil {
+			return "", err
+		}
+		elements := strings.Split(filepath.ToSlash(rendered), "/")
+		if t.templated() && strings.TrimSpace(elements[len(elements)-1]) == "" {
+			return "", nil
+		}
+		return rendered, nil
+	}
+	var rendered []string
+	elements, names := t.pathElements(rel, dir)
+	for i, element := range elements {
+		if names[i] {
+			var err error
+			if element, err = t.findReplaceWith(element, scope); err != nil {
+				return "", err
+			}
+		}
+		if t.templated() && i == len(elements)-1 && strings.TrimSpace(element) == "" {
+			return "", nil
+		}
+		rendered = append(rendered, element)
+	}
+	return string(filepath.Separator) + filepath.Join(rendered...), nil
+}
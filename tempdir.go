@@ -0,0 +1,76 @@
+package skel
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+)
+
+// Keeps track of temporary directories (unzipped skeletons, downloads,
+// staging areas), so they can be removed on every exit path.
+type TempManager struct {
+	mu   sync.Mutex
+	dirs []string
+}
+
+// The temporary directories of this run.
+var temps = new(TempManager)
+
+// Creates a new temporary directory with the given name prefix, which is
+// removed by Cleanup.
+func (m *TempManager) Dir(prefix string) (string, error) {
+	dir, err := ioutil.TempDir("", prefix)
+	if err != nil {
+		return "", err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.dirs = append(m.dirs, dir)
+
+	return dir, nil
+}
+
+// Removes the given temporary directory now, instead of at Cleanup.
+func (m *TempManager) Remove(dir string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, d := range m.dirs {
+		if d == dir {
+			m.dirs = append(m.dirs[:i], m.dirs[i+1:]...)
+			break
+		}
+	}
+	return m.remove(dir)
+}
+
+func (m *TempManager) remove(dir string) error {
+	debugf("Removing temporary directory '%s'\n", dir)
+	return os.RemoveAll(dir)
+}
+
+// Removes all temporary directories, in reverse order of creation. Failures
+// do not stop the removal of the other directories; the first is returned.
+func (m *TempManager) Cleanup() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var first error
+	for i := len(m.dirs) - 1; i >= 0; i-- {
+		if err := m.remove(m.dirs[i]); err != nil && first == nil {
+			first = fmt.Errorf("unable to remove directory '%s': %s", m.dirs[i], err)
+		}
+	}
+	m.dirs = nil
+	return first
+}
+
+// Releases all locks which are still held and removes all temporary
+// directories, like extracted skeletons. To be called when done with
+// skeletons, and when exiting early, e.g. on an interrupt.
+func Cleanup() error {
+	releaseLocks()
+	return temps.Cleanup()
+}
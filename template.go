@@ -0,0 +1,273 @@
+package skel
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// A single lexical token of a template: either literal text, or a ${..}
+// placeholder.
+type token struct {
+	text  string // literal text, or the full placeholder including ${ and }
+	tag   bool   // whether this is a placeholder
+	inner string // contents of the placeholder, between ${ and }
+}
+
+// Node types of a parsed template.
+type (
+	// Literal text, copied as-is.
+	textNode string
+
+	// A ${..} placeholder to be substituted by the value of a variable, or
+	// the result of an expression.
+	exprNode struct {
+		raw  string // the placeholder as written, including ${ and }
+		expr string // the contents of the placeholder
+	}
+
+	// A ${loop x} ... ${end} block, of which the body is repeated for every
+	// element in x.
+	loopNode struct {
+		raw  string // the opening tag as written
+		over string // name of the variable looped over
+		body []interface{}
+	}
+)
+
+// Returns the index of the closing brace of a placeholder of which the
+// contents start at index start, or -1 when there is none. Nested braces and
+// braces within double quoted strings are skipped.
+func closingBrace(src string, start int) int {
+	depth := 0
+	inString := false
+	for i := start; i < len(src); i++ {
+		c := src[i]
+		switch {
+		case inString && c == '\\':
+			i++
+		case c == '"':
+			inString = !inString
+		case inString:
+		case c == '{':
+			depth++
+		case c == '}':
+			if depth == 0 {
+				return i
+			}
+			depth--
+		}
+	}
+	return -1
+}
+
+// Matches block tags which are alone on their line, including the
+// surrounding whitespace and line terminator.
+var standaloneTag = regexp.MustCompile(`(?m)^[ \t]*(\$\{[ \t]*(?:loop[ \t]+[^\s}]+|end)[ \t]*\})[ \t]*\r?\n`)
+
+// Splits the src string into text and placeholder tokens.
+func lexTemplate(src string) []token {
+	// block tags on their own line should not leave empty lines in the output
+	src = standaloneTag.ReplaceAllString(src, "$1")
+
+	var tokens []token
+	for {
+		i := strings.Index(src, "${")
+		if i < 0 {
+			break
+		}
+		end := closingBrace(src, i+2)
+		if end < 0 {
+			// unterminated placeholder, regard the rest as text
+			break
+		}
+		if i > 0 {
+			tokens = append(tokens, token{text: src[:i]})
+		}
+		tokens = append(tokens, token{text: src[i : end+1], tag: true, inner: src[i+2 : end]})
+		src = src[end+1:]
+	}
+	if src != "" {
+		tokens = append(tokens, token{text: src})
+	}
+
+	return tokens
+}
+
+// Reports whether the placeholder contents denote a block tag, like
+// ${loop x} or ${end}.
+func isBlockTag(inner string) bool {
+	fields := strings.Fields(inner)
+	if len(fields) == 0 {
+		return false
+	}
+	return (fields[0] == "loop" && len(fields) == 2) || (fields[0] == "end" && len(fields) == 1)
+}
+
+// Parses the tokens into a tree of nodes, starting at *pos. When inLoop is
+// true, parsing stops after the ${end} tag closing the loop.
+func parseNodes(tokens []token, pos *int, inLoop bool) []interface{} {
+	var nodes []interface{}
+	for *pos < len(tokens) {
+		tok := tokens[*pos]
+		*pos++
+
+		if !tok.tag {
+			nodes = append(nodes, textNode(tok.text))
+			continue
+		}
+
+		fields := strings.Fields(tok.inner)
+		switch {
+		case isBlockTag(tok.inner) && fields[0] == "end" && inLoop:
+			return nodes
+		case isBlockTag(tok.inner) && fields[0] == "loop":
+			loop := loopNode{raw: tok.text, over: fields[1]}
+			// an unclosed loop extends to the end of the template
+			loop.body = parseNodes(tokens, pos, true)
+			nodes = append(nodes, loop)
+		default:
+			nodes = append(nodes, exprNode{raw: tok.text, expr: tok.inner})
+		}
+	}
+	return nodes
+}
+
+// Parses the given template source.
+func parseTemplate(src string) []interface{} {
+	pos := 0
+	return parseNodes(lexTemplate(src), &pos, false)
+}
+
+// Returns the elements to loop over for the given value. A number n results
+// in the elements 1 up to and including n, anything else is regarded as a
+// comma separated list.
+func loopItems(value string) []string {
+	if n, err := strconv.Atoi(strings.TrimSpace(value)); err == nil {
+		var items []string
+		for i := 1; i <= n; i++ {
+			items = append(items, strconv.Itoa(i))
+		}
+		return items
+	}
+	return listItems(value)
+}
+
+// Returns the elements of a comma separated list, like the value of a list
+// parameter. Unlike loopItems, a number is a single element.
+func listItems(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimFunc(item, unicode.IsSpace)
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// Returns a copy of the scope, with the ${loop.*} variables set for the
+// element at index i of the given items.
+func loopScope(scope map[string]string, items []string, i int) map[string]string {
+	child := make(map[string]string, len(scope)+4)
+	for k, v := range scope {
+		child[k] = v
+	}
+	child["loop.index"] = strconv.Itoa(i)
+	child["loop.number"] = strconv.Itoa(i + 1)
+	child["loop.value"] = items[i]
+	child["loop.count"] = strconv.Itoa(len(items))
+	return child
+}
+
+// Returns the substituted value, quoted when rendering a command.
+func (t Skeleton) quoted(value string) string {
+	if t.quote == nil {
+		return value
+	}
+	return t.quote(value)
+}
+
+// Renders the nodes using the variables in scope. Placeholders which cannot
+// be substituted are left as-is, and recorded in the Unsubstituted map.
+func (t Skeleton) renderNodes(nodes []interface{}, scope map[string]string, out *strings.Builder) {
+	for _, n := range nodes {
+		switch node := n.(type) {
+		case textNode:
+			out.WriteString(string(node))
+		case exprNode:
+			// a plain variable, possibly with a name which is not a valid
+			// identifier in an expression
+			if value, ok := scope[node.expr]; ok {
+				out.WriteString(t.quoted(value))
+				continue
+			}
+			// a bare literal or unknown name, like ${1} of a shell script,
+			// is no expression of the skeleton
+			if !computed(node.expr) {
+				t.Unsubstituted[node.raw] = true
+				out.WriteString(node.raw)
+				continue
+			}
+			value, err := Evaluate(node.expr, scope)
+			if err != nil {
+				t.Unsubstituted[node.raw] = true
+				out.WriteString(node.raw)
+				continue
+			}
+			out.WriteString(t.quoted(value))
+		case loopNode:
+			value, ok := scope[node.over]
+			if !ok {
+				t.Unsubstituted[node.raw] = true
+				continue
+			}
+			items := loopItems(value)
+			for i := range items {
+				t.renderNodes(node.body, loopScope(scope, items, i), out)
+			}
+		}
+	}
+}
+
+// Reports whether the placeholder is an expression which computes a value:
+// one with an operator, a function call or a pipe.
+func computed(expr string) bool {
+	tokens, err := lexExpr(expr)
+	if err != nil {
+		return false
+	}
+	for _, tok := range tokens {
+		if tok.kind == tokOperator {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the names of the variables referenced in the template source, in
+// placeholders, expressions and loops.
+func templateVariables(src string) []string {
+	var names []string
+	var collect func(nodes []interface{})
+	collect = func(nodes []interface{}) {
+		for _, n := range nodes {
+			switch node := n.(type) {
+			case exprNode:
+				names = append(names, node.expr)
+				tokens, _ := lexExpr(node.expr)
+				for _, tok := range tokens {
+					if tok.kind == tokIdent {
+						names = append(names, tok.text)
+					}
+				}
+			case loopNode:
+				names = append(names, node.over)
+				collect(node.body)
+			}
+		}
+	}
+	collect(parseTemplate(src))
+	return names
+}
@@ -0,0 +1,46 @@
+package skel
+
+import "testing"
+
+func TestFindReplaceLeavesLiterals(t *testing.T) {
+	skel := NewSkeleton("", SkeletonConfig{})
+	skel.KeyValues = map[string]string{"name": "abc", "port": "8080"}
+	cases := map[string]string{
+		"echo ${1} ${2}":  "echo ${1} ${2}",
+		"${1:-default}":   "${1:-default}",
+		"${HOME}/bin":     "${HOME}/bin",
+		`${"text"}`:       `${"text"}`,
+		"${name}":         "abc",
+		"${upper(name)}":  "ABC",
+		"${name | upper}": "ABC",
+		"${port + 1}":     "8081",
+	}
+	for src, want := range cases {
+		got, err := skel.findReplace(src)
+		if err != nil {
+			t.Errorf("findReplace(%q): %s", src, err)
+		} else if got != want {
+			t.Errorf("findReplace(%q) = %q, want %q", src, got, want)
+		}
+	}
+}
+
+func TestFindReplaceQuotesCommands(t *testing.T) {
+	skel := NewSkeleton("", SkeletonConfig{})
+	skel.KeyValues = map[string]string{"name": "x; rm -rf ~", "module": "github.com/org/x"}
+	skel.quote = shellQuote
+	cases := map[string]string{
+		"echo ${name}":          `echo 'x; rm -rf ~'`,
+		"echo ${upper(name)}":   `echo 'X; RM -RF ~'`,
+		"go mod init ${module}": "go mod init github.com/org/x",
+		"echo ${1}":             "echo ${1}",
+	}
+	for src, want := range cases {
+		got, err := skel.findReplace(src)
+		if err != nil {
+			t.Errorf("findReplace(%q): %s", src, err)
+		} else if got != want {
+			t.Errorf("findReplace(%q) = %q, want %q", src, got, want)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package skel
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+)
+
+// Returns the paths of the entries which exist in dir already, so merging
+// into it does not create them. The directory itself is included as ".".
+func existingPaths(dir string, entries []Entry) []string {
+	existing := []string{"."}
+	for _, e := range entries {
+		if _, err := os.Lstat(filepath.Join(dir, e.Path)); err == nil {
+			existing = append(existing, filepath.ToSlash(e.Path))
+		}
+	}
+	return existing
+}
+
+// What undoing a generation does to the project.
+type UndoPlan struct {
+	Files       []string // slash separated paths of files to remove
+	Directories []string // directories to remove when empty, deepest first
+	Modified    []string // files which were modified since, and are kept
+}
+
+// Plans undoing the generation recorded in the manifest of the project in
+// dir: the files it created which are unmodified, and the directories it
+// created. Paths which existed before the generation are left alone.
+func PlanUndo(dir string, m Manifest) (UndoPlan, error) {
+	plan := UndoPlan{}
+	existing := make(map[string]bool)
+	for _, p := range m.Existing {
+		existing[p] = true
+	}
+
+	for rel, sum := range m.Files {
+		if existing[rel] {
+			continue
+		}
+		path := filepath.Join(dir, filepath.FromSlash(rel))
+		info, err := os.Lstat(path)
+		switch {
+		case os.IsNotExist(err):
+			// removed already
+			continue
+		case err != nil:
+			return plan, err
+		case !info.Mode().IsRegular() && info.Mode()&os.ModeSymlink == 0:
+			plan.Modified = append(plan.Modified, rel)
+			continue
+		}
+		actual, err := pathDigest(path)
+		if err != nil {
+			return plan, err
+		}
+		if actual != sum {
+			plan.Modified = append(plan.Modified, rel)
+		} else {
+			plan.Files = append(plan.Files, rel)
+		}
+	}
+
+	for _, rel := range m.Directories {
+		if !existing[rel] {
+			plan.Directories = append(plan.Directories, rel)
+		}
+	}
+	// in reverse, children precede their parents
+	sort.Sort(sort.Reverse(sort.StringSlice(plan.Directories)))
+	sort.Strings(plan.Files)
+	sort.Strings(plan.Modified)
+	return plan, nil
+}
+
+// Removes the paths of the plan from the project in dir, and the files of skel
+// itself. Directories which are not empty, because files were added to them
+// or kept, are left. The project directory is removed when it was created by
+// the generation and is empty afterwards. Every path is reported to Log as it
+// is removed; a dry run only reports them.
+func (p UndoPlan) Apply(dir string, m Manifest, dry bool) error {
+	gone := make(map[string]bool)
+	remove := func(rel string) error {
+		gone[rel] = true
+		if dry {
+			logf("Would remove '%s'\n", rel)
+			return nil
+		}
+		logf("Removing '%s'\n", rel)
+		return os.Remove(filepath.Join(dir, filepath.FromSlash(rel)))
+	}
+	// whether the directory is empty once the removed paths are gone
+	empty := func(rel string) bool {
+		infos, err := ioutil.ReadDir(filepath.Join(dir, filepath.FromSlash(rel)))
+		if err != nil {
+			return false
+		}
+		for _, info := range infos {
+			if !gone[path.Join(rel, info.Name())] {
+				return false
+			}
+		}
+		return true
+	}
+
+	for _, rel := range p.Files {
+		if err := remove(rel); err != nil {
+			return err
+		}
+	}
+	for _, rel := range []string{ATTESTATION_FILE, ATTESTATION_SIGNATURE, ANSWERS_FILE, MANIFEST_FILE} {
+		if _, err := os.Lstat(filepath.Join(dir, rel)); err == nil {
+			if err := remove(rel); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, rel := range p.Directories {
+		if !empty(rel) {
+			logf("Keeping '%s', which is not empty\n", rel)
+			continue
+		}
+		if err := remove(rel); err != nil {
+			return err
+		}
+	}
+
+	if contains(m.Existing, ".") || !empty(".") {
+		return nil
+	}
+	if dry {
+		logf("Would remove '%s'\n", dir)
+		return nil
+	}
+	logf("Removing '%s'\n", dir)
+	return os.Remove(dir)
+}
@@ -0,0 +1,58 @@
+package skel
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// A single difference between a project and its skeleton.
+type Drift struct {
+	Kind string // "modified", "deleted", "added", or "skeleton" when it differs from the attested one
+	Path string // slash separated path, relative to the project
+}
+
+// Renders the skeleton again with the answers recorded in the manifest of the
+// project in dir, and compares the result to the files in the project. Files
+// which existed before the generation or were created by its hooks, and .git
+// directories, are not reported as added.
+func (t *Skeleton) Verify(dir string, m Manifest) ([]Drift, error) {
+	t.Restore(m, dir)
+	entries, err := t.Render()
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := projectFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, rel := range append(m.Existing, m.Hooked...) {
+		delete(existing, rel)
+	}
+
+	var drift []Drift
+	for _, e := range entriesIn(entries, "") {
+		if e.Dir {
+			continue
+		}
+		rel := filepath.ToSlash(e.Path)
+		delete(existing, rel)
+
+		sum, err := pathDigest(filepath.Join(dir, e.Path))
+		switch {
+		case os.IsNotExist(err):
+			drift = append(drift, Drift{"deleted", rel})
+		case err != nil:
+			return nil, err
+		case sum != e.contentDigest():
+			drift = append(drift, Drift{"modified", rel})
+		}
+	}
+	for rel := range existing {
+		drift = append(drift, Drift{"added", rel})
+	}
+
+	sort.Slice(drift, func(i, j int) bool { return drift[i].Path < drift[j].Path })
+	return drift, nil
+}
@@ -0,0 +1,49 @@
+package skel
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	VERSION = "1.1"
+)
+
+// Versions of the skeleton format (the format attribute of config.xml) which
+// this version of skel supports.
+var SupportedFormats = []string{"1"}
+
+// Checks whether the skeleton format is supported. An empty format denotes
+// the first version.
+func checkFormat(format string) error {
+	if format == "" {
+		format = "1"
+	}
+	if !contains(SupportedFormats, format) {
+		return fmt.Errorf("skeleton format '%s' is not supported by skel v%s (supported: %s)",
+			format, VERSION, strings.Join(SupportedFormats, ", "))
+	}
+	return nil
+}
+
+// Compares two dotted version numbers, ignoring a leading "v". Returns a
+// negative number when a < b, zero when equal and a positive number when
+// a > b. Non-numeric parts compare as zero.
+func CompareVersions(a, b string) int {
+	as := strings.Split(strings.TrimPrefix(a, "v"), ".")
+	bs := strings.Split(strings.TrimPrefix(b, "v"), ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var x, y int
+		if i < len(as) {
+			x, _ = strconv.Atoi(as[i])
+		}
+		if i < len(bs) {
+			y, _ = strconv.Atoi(bs[i])
+		}
+		if x != y {
+			return x - y
+		}
+	}
+	return 0
+}